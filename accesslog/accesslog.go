@@ -0,0 +1,186 @@
+// Package accesslog provides HTTP middleware that writes access logs in the
+// Apache Common or Combined Log Format, so that a web service can keep
+// rotated access logs by pointing it at a lumberjack.Logger:
+//
+//	http.ListenAndServe(":8080", accesslog.CombinedHandler(&lumberjack.Logger{
+//	    Filename:   "/var/log/myapp/access.log",
+//	    MaxSize:    500, // megabytes
+//	    MaxBackups: 3,
+//	}, mux))
+//
+// Each request is logged with a single Write once its handler has returned,
+// so lines from concurrent requests don't get mixed up.
+package accesslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now exists so it can be mocked out by tests.
+var now = time.Now
+
+// CommonHandler returns an http.Handler that serves requests with h and
+// writes a line for each one to w in the Common Log Format:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+func CommonHandler(w io.Writer, h http.Handler) http.Handler {
+	return &handler{w: w, h: h}
+}
+
+// CombinedHandler returns an http.Handler that serves requests with h and
+// writes a line for each one to w in the Combined Log Format, which is the
+// Common Log Format with the Referer and User-Agent headers added:
+//
+//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+func CombinedHandler(w io.Writer, h http.Handler) http.Handler {
+	return &handler{w: w, h: h, combined: true}
+}
+
+type handler struct {
+	w        io.Writer
+	h        http.Handler
+	combined bool
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := now()
+	rw := &responseWriter{ResponseWriter: w}
+	h.h.ServeHTTP(rw, r)
+	// errors writing the log can't be reported to anyone.
+	_, _ = h.w.Write(h.line(r, rw, start))
+}
+
+// line formats the log line for r, whose response was rw.
+func (h *handler) line(r *http.Request, rw *responseWriter, start time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	}
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	size := "-"
+	if rw.size > 0 {
+		size = strconv.FormatInt(rw.size, 10)
+	}
+
+	b := make([]byte, 0, 256)
+	b = append(b, escape(host)...)
+	b = append(b, " - "...)
+	b = append(b, escape(user)...)
+	b = append(b, " ["...)
+	b = start.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	b = append(b, `] "`...)
+	b = append(b, escape(fmt.Sprintf("%s %s %s", r.Method, uri, r.Proto))...)
+	b = append(b, `" `...)
+	b = strconv.AppendInt(b, int64(rw.status()), 10)
+	b = append(b, ' ')
+	b = append(b, size...)
+	if h.combined {
+		b = append(b, ` "`...)
+		b = append(b, escape(orDash(r.Referer()))...)
+		b = append(b, `" "`...)
+		b = append(b, escape(orDash(r.UserAgent()))...)
+		b = append(b, '"')
+	}
+	return append(b, '\n')
+}
+
+// orDash returns s, or "-" if s is empty, as Apache logs missing values.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// escape escapes quotes, backslashes and unprintable bytes in s the way
+// Apache does, so that a line can't be broken up or faked by what a client
+// sends.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&b, "\\x%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	code int
+	size int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher, if the underlying ResponseWriter does.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, if the underlying ResponseWriter does.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	if w.code == 0 {
+		w.code = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// status returns the status code that was sent.
+func (w *responseWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+	return w.code
+}
@@ -0,0 +1,90 @@
+package accesslog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+func fakeNow() time.Time {
+	return time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+}
+
+func TestCombinedHandler(t *testing.T) {
+	now = fakeNow
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	h := CombinedHandler(&buf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("not here"))
+	}))
+
+	r := httptest.NewRequest("GET", "/apache_pb.gif?x=1", nil)
+	r.RemoteAddr = "127.0.0.1:1234"
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("Referer", "http://www.example.com/start.html")
+	r.Header.Set("User-Agent", `Mozilla/4.08 "quoted"`)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif?x=1 HTTP/1.1" 404 8 "http://www.example.com/start.html" "Mozilla/4.08 \"quoted\""` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestCommonHandler(t *testing.T) {
+	now = fakeNow
+	defer func() { now = time.Now }()
+
+	var buf bytes.Buffer
+	h := CommonHandler(&buf, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("POST", "/", nil)
+	r.RequestURI = "/a\nb"
+	r.RemoteAddr = "[::1]:80"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := `::1 - - [10/Oct/2000:13:55:36 -0700] "POST /a\x0ab HTTP/1.1" 200 -` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestHandlerWithLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestHandlerWithLogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &lumberjack.Logger{Filename: filepath.Join(dir, "access.log")}
+	defer l.Close()
+
+	srv := httptest.NewServer(CombinedHandler(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hi"))
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	b, err := ioutil.ReadFile(l.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"GET /hello HTTP/1.1" 200 2 "-" "Go-http-client/1.1"`) {
+		t.Errorf("unexpected log line %q", b)
+	}
+}
@@ -0,0 +1,102 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"time"
+)
+
+// defaultFlushInterval is how often the gzip stream is flushed if
+// CompressFlushInterval isn't set.
+const defaultFlushInterval = time.Second
+
+// startGzip starts a gzip stream in the newly opened log file if
+// CompressActive is set.  If the file already has data in it, the stream is
+// a new gzip member after the existing ones, which gzip readers concatenate.
+func (l *Logger) startGzip() {
+	if l.CompressActive {
+		l.gz = gzip.NewWriter(fileSink{l})
+	}
+}
+
+// stopGzip finishes the gzip stream in the log file, if there is one.
+func (l *Logger) stopGzip() error {
+	if l.gz == nil {
+		return nil
+	}
+	if l.flushTimer != nil {
+		l.flushTimer.Stop()
+		l.flushTimer = nil
+	}
+	err := l.gz.Close()
+	l.gz = nil
+	return err
+}
+
+// flushGzip writes what the gzip stream has buffered to the log file, so that
+// it can be read.
+func (l *Logger) flushGzip() error {
+	if l.gz == nil {
+		return nil
+	}
+	if l.flushTimer != nil {
+		l.flushTimer.Stop()
+		l.flushTimer = nil
+	}
+	err := l.gz.Flush()
+	l.wakeFollowers()
+	return err
+}
+
+// scheduleFlush makes sure the gzip stream is flushed within
+// CompressFlushInterval of data being written to it.
+func (l *Logger) scheduleFlush() {
+	if l.flushTimer != nil {
+		return
+	}
+	d := l.CompressFlushInterval
+	if d <= 0 {
+		d = defaultFlushInterval
+	}
+	var t *time.Timer
+	t = time.AfterFunc(d, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		// the stream may have been flushed or closed, and another timer
+		// started, since this one fired.
+		if l.flushTimer == t {
+			l.flushTimer = nil
+			_ = l.gz.Flush()
+			l.wakeFollowers()
+		}
+	})
+	l.flushTimer = t
+}
+
+// writeFile writes p to the log file, through the gzip stream if there is
+// one, keeping track of how big the file on disk is.
+func (l *Logger) writeFile(p []byte) (int, error) {
+	if l.gz != nil {
+		n, err := l.gz.Write(p)
+		l.scheduleFlush()
+		return n, err
+	}
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+	return n, err
+}
+
+// fileSink writes compressed data from the gzip stream to the log file.
+type fileSink struct {
+	l *Logger
+}
+
+func (s fileSink) Write(p []byte) (int, error) {
+	n, err := s.l.file.Write(p)
+	s.l.size += int64(n)
+	return n, err
+}
+
+// writerFunc is an io.Writer made from a function.
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
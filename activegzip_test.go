@@ -0,0 +1,204 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readGzip returns the decompressed content of the gzip file name, as far as
+// it can be read.
+func readGzip(name string, t testing.TB) string {
+	f, err := os.Open(name)
+	isNilUp(err, t, 1)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	isNilUp(err, t, 1)
+	b, err := ioutil.ReadAll(r)
+	if err != io.ErrUnexpectedEOF {
+		isNilUp(err, t, 1)
+	}
+	return string(b)
+}
+
+func TestCompressActive(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressActive", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:              logFile(dir),
+		CompressActive:        true,
+		CompressFlushInterval: 10 * time.Millisecond,
+		MaxSize:               1000,
+		ManualCleanup:         true,
+		ReopenOnWrite:         true,
+	}
+	defer l.Close()
+
+	filename := logFile(dir) + compressSuffix
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	notExist(logFile(dir), t)
+
+	// what is written shows up in the file once it has been flushed.
+	<-time.After(100 * time.Millisecond)
+	equals("boo!\n", readGzip(filename, t), t)
+
+	// reopening appends a new gzip member.
+	isNil(l.Close(), t)
+	_, err = l.Write([]byte("foo!\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	equals("boo!\nfoo!\n", readGzip(filename, t), t)
+
+	// rotation finishes the stream and starts a new file, and the backup is
+	// named like an ordinary compressed backup.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(backupTimeFormat)+".log"+compressSuffix)
+	equals("boo!\nfoo!\n", readGzip(backup, t), t)
+	_, err = l.Write([]byte("bar!\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+	equals("bar!\n", readGzip(filename, t), t)
+	fileCount(dir, 2, t)
+
+	r, err := l.OpenBackupAt(0)
+	isNil(err, t)
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("boo!\nfoo!\n", string(b), t)
+}
+
+func TestCompressActiveMaxSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressActiveMaxSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		CompressActive: true,
+		MaxSize:        100,
+		MaxBackups:     1,
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+
+	// MaxSize applies to the compressed size, which flushing brings up to
+	// date.
+	line := []byte("a highly compressible line of log\n")
+	for i := 0; i < 20; i++ {
+		_, err := l.Write(line)
+		isNil(err, t)
+	}
+	_, err := l.WriteAndSync(line)
+	isNil(err, t)
+	fileCount(dir, 1, t)
+
+	// each flush adds a few bytes, until the log file has to be rotated.
+	for i := 0; i < 20; i++ {
+		newFakeTime()
+		_, err := l.WriteAndSync(line)
+		isNil(err, t)
+	}
+	files, err := ioutil.ReadDir(dir)
+	isNil(err, t)
+	assert(len(files) > 1, t, "expected the log file to have been rotated")
+}
+
+func TestCompressActiveReaders(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressActiveReaders", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		CompressActive: true,
+		MaxSize:        1000,
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	// the gzip stream in the current log file is left unfinished.
+	_, err = l.WriteAndSync([]byte("two\nthree\n"))
+	isNil(err, t)
+
+	lines, err := l.Tail(2)
+	isNil(err, t)
+	equals([][]byte{[]byte("two"), []byte("three")}, lines, t)
+	lines, err = l.Tail(5)
+	isNil(err, t)
+	equals([][]byte{[]byte("one"), []byte("two"), []byte("three")}, lines, t)
+
+	r, err := l.OpenHistory()
+	isNil(err, t)
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("one\ntwo\nthree\n", string(b), t)
+
+	r, err = l.ReadRange(fakeTime().Add(-time.Minute), time.Time{})
+	isNil(err, t)
+	b, err = ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("one\ntwo\nthree\n", string(b), t)
+}
+
+func TestCompressActiveFollow(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressActiveFollow", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:              logFile(dir),
+		CompressActive:        true,
+		CompressFlushInterval: 10 * time.Millisecond,
+		MaxSize:               1000,
+		ManualCleanup:         true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, err := l.Follow(ctx)
+	isNil(err, t)
+	defer r.Close()
+
+	// what is read is decompressed, as soon as it has been flushed.
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(r, buf)
+	isNil(err, t)
+	equals("one\n", string(buf), t)
+
+	// and following carries on into the next file after a rotation.
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		l.Write([]byte("two\n"))
+		newFakeTime()
+		l.Rotate()
+		l.Write([]byte("three\n"))
+	}()
+	buf = make([]byte, 10)
+	_, err = io.ReadFull(r, buf)
+	isNil(err, t)
+	equals("two\nthree\n", string(buf), t)
+}
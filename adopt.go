@@ -0,0 +1,104 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Adopt moves the file at path, which was made by something other than the
+// Logger, such as a panic dump or a log copied in from another host, into
+// the log file's directory under a backup's name, so that it is compressed,
+// uploaded and eventually removed like any other backup.  The backup's time
+// is the file's modification time, moved on by a millisecond at a time if a
+// backup already has that name.  A compressed file keeps its compression
+// suffix; with CompressActive, only gzipped files can be adopted.  Adopt
+// returns the file's new path.  If path is on another filesystem, it is
+// copied and then removed.
+func (l *Logger) Adopt(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("can't adopt %s: not a regular file", path)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.checkClosed(); err != nil {
+		return "", err
+	}
+
+	name := l.filename()
+	suffix := ""
+	if c := codecFor(path); c != nil {
+		suffix = c.ext
+	}
+	if l.CompressActive {
+		if suffix != compressSuffix {
+			return "", fmt.Errorf("can't adopt %s: with CompressActive, backups must be gzipped", path)
+		}
+		name = strings.TrimSuffix(name, compressSuffix)
+	}
+	if err := os.MkdirAll(l.dir(), 0755); err != nil {
+		return "", fmt.Errorf("can't make directories for backup: %w", err)
+	}
+
+	var dst string
+	for t := info.ModTime(); ; t = t.Add(time.Millisecond) {
+		dst = backupNameAt(name, t, l.LocalTime) + suffix
+		if _, err := os.Lstat(dst); os.IsNotExist(err) {
+			break
+		}
+	}
+	err = l.renameBackup(path, dst)
+	if isCrossDevice(err) {
+		err = moveFile(path, dst, info.Mode())
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't adopt %s: %w", path, err)
+	}
+
+	l.queueUpload(dst)
+	l.linkBackup(dst)
+	atomic.AddInt64(&l.backupBytes, info.Size())
+	if suffix != "" || !l.compresses(info.Size()) {
+		if err := l.makeReadOnly(dst); err != nil {
+			return dst, err
+		}
+	}
+	l.mill()
+	return dst, nil
+}
+
+// moveFile copies src to dst, which mustn't exist, with the given mode, and
+// then removes src.
+func moveFile(src, dst string, mode os.FileMode) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(dst)
+		}
+	}()
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,51 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAdopt(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestAdopt", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	dump := filepath.Join(dir, "panic.txt")
+	isNil(ioutil.WriteFile(dump, []byte("panic!"), 0644), t)
+	mtime := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	isNil(os.Chtimes(dump, mtime, mtime), t)
+
+	name, err := l.Adopt(dump)
+	isNil(err, t)
+	equals(filepath.Join(dir, "foobar-"+mtime.Format(backupTimeFormat)+".log"), name, t)
+	notExist(dump, t)
+	existsWithContent(name, []byte("panic!"), t)
+
+	// another file from the same moment gets the next free name.
+	isNil(ioutil.WriteFile(dump, []byte("again!"), 0644), t)
+	isNil(os.Chtimes(dump, mtime, mtime), t)
+	name2, err := l.Adopt(dump)
+	isNil(err, t)
+	equals(filepath.Join(dir, "foobar-"+mtime.Add(time.Millisecond).Format(backupTimeFormat)+".log"), name2, t)
+
+	// adopted files are backups like any other.
+	isNil(l.CleanNow(), t)
+	exists(name+compressSuffix, t)
+	exists(name2+compressSuffix, t)
+
+	_, err = l.Adopt(dir)
+	notNil(err, t)
+	_, err = l.Adopt(filepath.Join(dir, "nope"))
+	assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+}
@@ -0,0 +1,13 @@
+// +build !linux
+
+package lumberjack
+
+import (
+	"os"
+)
+
+// setAppendOnly does nothing, since the append-only attribute isn't supported
+// on this platform.
+func setAppendOnly(_ *os.File, _ bool) error {
+	return nil
+}
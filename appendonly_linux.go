@@ -0,0 +1,49 @@
+// +build amd64 arm64 386 arm riscv64 loong64 s390x
+
+package lumberjack
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// the ioctls for the inode flags, as encoded on architectures that use the
+// generic ioctl layout.  The argument is declared as a long, though the
+// kernel only ever reads and writes an int.
+const (
+	fsIocGetflags = 2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1
+	fsIocSetflags = 1<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 2
+	fsAppendFl    = 0x20
+)
+
+// setAppendOnly sets or clears the append-only attribute of f, which makes
+// the kernel refuse to truncate, rename or remove it, or open it for writing
+// other than to append.  Setting it needs CAP_LINUX_IMMUTABLE.
+func setAppendOnly(f *os.File, on bool) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		var flags int32
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, fsIocGetflags, uintptr(unsafe.Pointer(&flags)))
+		if errno != 0 {
+			return
+		}
+		if on {
+			flags |= fsAppendFl
+		} else {
+			flags &^= fsAppendFl
+		}
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, fsIocSetflags, uintptr(unsafe.Pointer(&flags)))
+	})
+	if err != nil {
+		return err
+	}
+	if errno != 0 {
+		return os.NewSyscallError("ioctl", errno)
+	}
+	return nil
+}
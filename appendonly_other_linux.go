@@ -0,0 +1,19 @@
+// +build !amd64,!arm64,!386,!arm,!riscv64,!loong64,!s390x
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"runtime"
+)
+
+// setAppendOnly fails to set the append-only attribute, since the ioctl for
+// it isn't encoded for this architecture, so that AppendOnly isn't silently
+// ignored.  Clearing it does nothing, as it can never have been set.
+func setAppendOnly(_ *os.File, on bool) error {
+	if !on {
+		return nil
+	}
+	return errors.New("the append-only attribute is not supported on linux/" + runtime.GOARCH)
+}
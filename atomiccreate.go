@@ -0,0 +1,21 @@
+// +build !linux !amd64,!arm64,!386,!arm,!riscv64,!loong64,!s390x
+
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// createUnnamed always fails, since files without a name aren't supported on
+// this platform.
+func createUnnamed(_ string, _ os.FileMode) (*os.File, error) {
+	return nil, errNoUnnamed
+}
+
+// linkUnnamed is never called, since createUnnamed always fails.
+func linkUnnamed(_ *os.File, _ string) error {
+	return errNoUnnamed
+}
+
+var errNoUnnamed = errors.New("unnamed files are not available on this platform")
@@ -0,0 +1,55 @@
+// +build amd64 arm64 386 arm riscv64 loong64 s390x
+
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// oTmpfile is O_TMPFILE, which the syscall package doesn't define.  It is
+// the same on all the architectures this file is built for, once combined
+// with their own O_DIRECTORY.
+const oTmpfile = 0x400000 | syscall.O_DIRECTORY
+
+// createUnnamed creates a file in the directory of name that has no name yet,
+// so that it can be made ready before linkUnnamed gives it the name.
+func createUnnamed(name string, mode os.FileMode) (*os.File, error) {
+	fd, err := syscall.Open(filepath.Dir(name), oTmpfile|syscall.O_WRONLY|syscall.O_APPEND|syscall.O_CLOEXEC, uint32(mode.Perm()))
+	if err != nil {
+		return nil, os.NewSyscallError("open", err)
+	}
+	return os.NewFile(uintptr(fd), name), nil
+}
+
+// linkUnnamed gives f, made by createUnnamed, the name name, replacing any
+// file already there in one step.  The file is first linked under a
+// temporary name, since linkat won't replace an existing file.
+func linkUnnamed(f *os.File, name string) error {
+	tmp := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".new")
+	// left over from a crash, maybe.
+	os.Remove(tmp)
+	from, err := syscall.BytePtrFromString(fmt.Sprintf("/proc/self/fd/%d", f.Fd()))
+	if err != nil {
+		return err
+	}
+	to, err := syscall.BytePtrFromString(tmp)
+	if err != nil {
+		return err
+	}
+	atFdcwd := -0x64
+	const atSymlinkFollow = 0x400
+	_, _, errno := syscall.Syscall6(syscall.SYS_LINKAT, uintptr(atFdcwd), uintptr(unsafe.Pointer(from)),
+		uintptr(atFdcwd), uintptr(unsafe.Pointer(to)), atSymlinkFollow, 0)
+	if errno != 0 {
+		return os.NewSyscallError("linkat", errno)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package lumberjack
+
+import (
+	"io"
+	"time"
+)
+
+// batchLimit is the most that is gathered into one batch for BatchWindow.
+// Writes at least this big aren't batched.
+const batchLimit = 64 * 1024
+
+// writeBatch is a group of writes that are made to the log file as one.
+type writeBatch struct {
+	buf  []byte
+	full chan struct{}
+	done chan struct{}
+	n    int
+	err  error
+}
+
+// writeBatched adds p to the current batch, starting one if need be, and
+// waits for the batch to be written.  The writer that starts a batch waits
+// BatchWindow, or until the batch is full, and then writes it for everyone.
+func (l *Logger) writeBatched(p []byte) (int, error) {
+	limit := l.max()
+	if limit > batchLimit {
+		limit = batchLimit
+	}
+	if int64(len(p)) >= limit {
+		return l.writeDirect(p)
+	}
+
+	l.batchMu.Lock()
+	b := l.batch
+	if b != nil && int64(len(b.buf)+len(p)) > limit {
+		// no room: that batch is on its way, and this write starts the next.
+		l.batch = nil
+		close(b.full)
+		b = nil
+	}
+	lead := b == nil
+	if lead {
+		b = &writeBatch{full: make(chan struct{}), done: make(chan struct{})}
+		l.batch = b
+	}
+	start := len(b.buf)
+	b.buf = append(b.buf, p...)
+	l.batchMu.Unlock()
+
+	if lead {
+		t := time.NewTimer(l.BatchWindow)
+		select {
+		case <-t.C:
+		case <-b.full:
+			t.Stop()
+		}
+		l.batchMu.Lock()
+		if l.batch == b {
+			l.batch = nil
+		}
+		l.batchMu.Unlock()
+
+		b.n, b.err = l.writeDirect(b.buf)
+		close(b.done)
+	} else {
+		<-b.done
+	}
+
+	// work out how much of p made it, if not all of the batch did.
+	n := b.n - start
+	if n > len(p) {
+		n = len(p)
+	} else if n < 0 {
+		n = 0
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	if b.err == nil {
+		return n, io.ErrShortWrite
+	}
+	return n, b.err
+}
+
+// flushBatch writes the batch that is gathering, if there is one, without
+// waiting for the rest of BatchWindow, and waits for it to be written.
+func (l *Logger) flushBatch() {
+	l.batchMu.Lock()
+	b := l.batch
+	if b != nil {
+		l.batch = nil
+		close(b.full)
+	}
+	l.batchMu.Unlock()
+	if b != nil {
+		<-b.done
+	}
+}
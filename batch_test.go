@@ -0,0 +1,69 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchWindow(t *testing.T) {
+	// every rotation needs a different time, for its backup's name.
+	var mu sync.Mutex
+	currentTime = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		fakeCurrentTime = fakeCurrentTime.Add(time.Second)
+		return fakeCurrentTime
+	}
+	defer func() { currentTime = fakeTime }()
+	megabyte = 1
+
+	dir := makeTempDir("TestBatchWindow", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:    logFile(dir),
+		MaxSize:     100,
+		BatchWindow: 50 * time.Millisecond,
+	}
+	defer l.Close()
+
+	// 50 writes of 10 bytes don't fit in one file, so they go in batches of
+	// no more than MaxSize, and only take about one window each.
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			line := []byte(fmt.Sprintf("line %04d\n", i))
+			n, err := l.Write(line)
+			isNil(err, t)
+			equals(len(line), n, t)
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	assert(elapsed < 20*50*time.Millisecond, t, "batched writes took %v", elapsed)
+
+	// every line made it, whole, and no file is over MaxSize.
+	files, err := ioutil.ReadDir(dir)
+	isNil(err, t)
+	var all []byte
+	for _, f := range files {
+		assert(f.Size() <= 100, t, "%s is %d bytes", f.Name(), f.Size())
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		isNil(err, t)
+		all = append(all, b...)
+	}
+	for i := 0; i < 50; i++ {
+		line := []byte(fmt.Sprintf("line %04d\n", i))
+		equals(1, bytes.Count(all, line), t)
+	}
+	equals(500, len(all), t)
+}
@@ -0,0 +1,88 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// DiskBudget limits the disk space used by several Loggers together, such as
+// all the Loggers of a process that share a volume.  Set the Budget field of
+// each Logger to the same DiskBudget.  Whenever one of them cleans up, the
+// oldest backups across all of them are deleted until the log files and
+// backups fit within MaxSize.  A Logger joins the budget when it first cleans
+// up, which happens when it is first written to, and stays in it for the life
+// of the DiskBudget.
+type DiskBudget struct {
+	// MaxSize is the maximum size in megabytes of the log files and backups
+	// of all the Loggers put together.
+	MaxSize int `json:"maxsize" yaml:"maxsize"`
+
+	mu      sync.Mutex
+	loggers map[*Logger]struct{}
+}
+
+// budgetFile is a backup of one of the Loggers sharing a DiskBudget.
+type budgetFile struct {
+	logInfo
+	owner *Logger
+}
+
+// enforce deletes the oldest backups of the Loggers in the budget until they
+// fit, adding l to the budget first.
+func (b *DiskBudget) enforce(l *Logger) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.loggers == nil {
+		b.loggers = make(map[*Logger]struct{})
+	}
+	b.loggers[l] = struct{}{}
+
+	// keep the Loggers' own cleanup out of the way while we look at and
+	// remove their files.  Nothing else takes more than one of these locks,
+	// and only while holding b.mu, so this can't deadlock.
+	for m := range b.loggers {
+		m.millMu.Lock()
+		defer m.millMu.Unlock()
+	}
+
+	var total int64
+	var files []budgetFile
+	var errs []error
+	for m := range b.loggers {
+		if info, err := m.stat(m.filename()); err == nil {
+			total += info.Size()
+		}
+		backups, err := m.oldLogFiles()
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		for _, f := range m.prunable(backups) {
+			files = append(files, budgetFile{f, m})
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].timestamp.After(files[j].timestamp)
+	})
+
+	max := int64(b.MaxSize) * int64(megabyte)
+	for _, f := range files {
+		total += f.Size()
+		// a Logger whose maintenance is paused keeps its backups.
+		if total <= max || f.owner.maintenancePaused() {
+			continue
+		}
+		fn := filepath.Join(f.owner.dir(), f.Name())
+		err := failed(ErrRemoveFailed, removeFile(fn))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		f.owner.emit(Event{Type: EventRemove, Filename: fn, Size: f.Size(), Err: err})
+	}
+	return errors.Join(errs...)
+}
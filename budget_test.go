@@ -0,0 +1,58 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskBudget(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestDiskBudget", t)
+	defer os.RemoveAll(dir)
+
+	budget := &DiskBudget{MaxSize: 30}
+	a := &Logger{
+		Filename:      filepath.Join(dir, "a", "foobar.log"),
+		Budget:        budget,
+		ManualCleanup: true,
+	}
+	defer a.Close()
+	b := &Logger{
+		Filename:      filepath.Join(dir, "b", "foobar.log"),
+		Budget:        budget,
+		ManualCleanup: true,
+	}
+	defer b.Close()
+
+	// a's backups are older than b's.
+	backup := func(l *Logger) string {
+		newFakeTime()
+		_, err := l.Write([]byte("0123456789"))
+		isNil(err, t)
+		isNil(l.Rotate(), t)
+		return backupFile(filepath.Dir(l.Filename))
+	}
+	a1 := backup(a)
+	a2 := backup(a)
+	b1 := backup(b)
+	b2 := backup(b)
+
+	isNil(a.CleanNow(), t)
+	// only a is known to the budget so far, and its files fit.
+	exists(a1, t)
+
+	isNil(b.CleanNow(), t)
+	notExist(a1, t)
+	exists(a2, t)
+	exists(b1, t)
+
+	// the log files count too.
+	isNil(ioutil.WriteFile(a.Filename, []byte("0123456789"), 0644), t)
+	isNil(a.CleanNow(), t)
+	notExist(a2, t)
+	exists(b1, t)
+	exists(b2, t)
+}
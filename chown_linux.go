@@ -5,15 +5,20 @@ import (
 	"syscall"
 )
 
-// osChown is a var so we can mock it out during tests.
-var osChown = os.Chown
-
-func chown(name string, info os.FileInfo) error {
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+func (l *Logger) chown(name string, info os.FileInfo) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|l.noFollow(), info.Mode())
 	if err != nil {
 		return err
 	}
 	f.Close()
-	stat := info.Sys().(*syscall.Stat_t)
-	return osChown(name, int(stat.Uid), int(stat.Gid))
+	// a FileInfo from a custom Stat may not carry an owner at all.
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	chown := os.Chown
+	if l.Chown != nil {
+		chown = l.Chown
+	}
+	return chown(name, int(stat.Uid), int(stat.Gid))
 }
@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// chunkProgress records how far compressing a backup in chunks has got.
+type chunkProgress struct {
+	// Source and Size are the name and size of the backup being compressed,
+	// so that progress left over for some other file isn't used.
+	Source string `json:"source"`
+	Size   int64  `json:"size"`
+
+	// Read is how much of the backup has been compressed, and Written is how
+	// long the compressed file was when the last chunk was complete.
+	Read    int64 `json:"read"`
+	Written int64 `json:"written"`
+}
+
+// chunked reports whether backups should be compressed with c in chunks.
+func (l *Logger) chunked(c *codec) bool {
+	return l.CompressChunkSize > 0 && c.ext == compressSuffix
+}
+
+// progressName returns the name of the file that records how far
+// compressing into name has got.  It starts with a dot so that it isn't
+// taken for a backup.
+func progressName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".progress")
+}
+
+// readProgress returns the progress recorded for compressing the backup
+// described by info into name, if there is any.
+func readProgress(name string, info os.FileInfo) (chunkProgress, bool) {
+	var p chunkProgress
+	b, err := ioutil.ReadFile(progressName(name))
+	if err != nil || json.Unmarshal(b, &p) != nil {
+		return chunkProgress{}, false
+	}
+	if p.Source != info.Name() || p.Size != info.Size() || p.Read > p.Size {
+		return chunkProgress{}, false
+	}
+	fi, err := os.Stat(name)
+	if err != nil || fi.Size() < p.Written {
+		return chunkProgress{}, false
+	}
+	return p, true
+}
+
+// compressChunks gzips the backup f, described by info, into the file name,
+// a chunk of CompressChunkSize megabytes at a time, recording its progress
+// after each one.  If progress was recorded by an earlier attempt that was
+// interrupted, it carries on from there.
+func (l *Logger) compressChunks(f *os.File, name string, info os.FileInfo, keep bool) error {
+	flags := os.O_CREATE | os.O_WRONLY | l.noFollow()
+	p, ok := readProgress(name, info)
+	if !ok {
+		p = chunkProgress{Source: info.Name(), Size: info.Size()}
+		if err := l.chown(name, info); err != nil {
+			return err
+		}
+		flags |= os.O_TRUNC
+	}
+	gzf, err := os.OpenFile(name, flags, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer gzf.Close()
+
+	// anything after the last complete chunk is thrown away
+	if err := gzf.Truncate(p.Written); err != nil {
+		return err
+	}
+	if _, err := gzf.Seek(p.Written, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := f.Seek(p.Read, io.SeekStart); err != nil {
+		return err
+	}
+
+	size := int64(l.CompressChunkSize) * int64(megabyte)
+	for p.Written == 0 || p.Read < p.Size {
+		gz := gzip.NewWriter(gzf)
+		if keep && p.Read == 0 {
+			gz.Name = info.Name()
+			gz.ModTime = info.ModTime()
+		}
+		n, err := io.Copy(gz, l.compressSource(io.LimitReader(f, size)))
+		if err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return err
+		}
+		if err := gzf.Sync(); err != nil {
+			return err
+		}
+		if p.Written, err = gzf.Seek(0, io.SeekCurrent); err != nil {
+			return err
+		}
+		p.Read += n
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(progressName(name), b, 0600); err != nil {
+			return err
+		}
+		if n == 0 {
+			// the backup is shorter than it was
+			break
+		}
+	}
+	if err := gzf.Close(); err != nil {
+		return err
+	}
+	return os.Remove(progressName(name))
+}
@@ -0,0 +1,139 @@
+package lumberjack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestCompressChunkSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressChunkSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		Compress:          true,
+		CompressChunkSize: 3,
+		ManualCleanup:     true,
+	}
+	defer l.Close()
+
+	name := backupFile(dir)
+	data := []byte("boo!boo!boo!")
+	isNil(ioutil.WriteFile(name, data, 0644), t)
+	isNil(l.CleanNow(), t)
+	notExist(name, t)
+	notExist(progressName(name+compressSuffix), t)
+	equals(data, gunzipMembers(name+compressSuffix, 4, t), t)
+}
+
+func TestCompressChunkResume(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressChunkResume", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		Compress:          true,
+		CompressChunkSize: 4,
+		ManualCleanup:     true,
+	}
+	defer l.Close()
+
+	name := backupFile(dir)
+	isNil(ioutil.WriteFile(name, []byte("boo!boo!boo!"), 0644), t)
+
+	// an earlier attempt got through the first chunk, which is told apart
+	// here by being upper case, and part of the way through the second
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("BOO!"))
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	written := int64(buf.Len())
+	buf.WriteString("partial")
+	dst := name + compressSuffix
+	isNil(ioutil.WriteFile(dst, buf.Bytes(), 0644), t)
+	info, err := os.Stat(name)
+	isNil(err, t)
+	b, err := json.Marshal(chunkProgress{Source: info.Name(), Size: info.Size(), Read: 4, Written: written})
+	isNil(err, t)
+	isNil(ioutil.WriteFile(progressName(dst), b, 0644), t)
+
+	isNil(l.CleanNow(), t)
+	notExist(name, t)
+	notExist(progressName(dst), t)
+	equals([]byte("BOO!boo!boo!"), gunzipMembers(dst, 3, t), t)
+
+	// progress recorded for another file is ignored
+	isNil(ioutil.WriteFile(name, []byte("boo!boo!boo!"), 0644), t)
+	isNil(ioutil.WriteFile(dst, buf.Bytes(), 0644), t)
+	b, err = json.Marshal(chunkProgress{Source: "other.log", Size: info.Size(), Read: 4, Written: written})
+	isNil(err, t)
+	isNil(ioutil.WriteFile(progressName(dst), b, 0644), t)
+	isNil(l.CleanNow(), t)
+	equals([]byte("boo!boo!boo!"), gunzipMembers(dst, 3, t), t)
+}
+
+func TestCompressChunkFailure(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressChunkFailure", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:          logFile(dir),
+		Compress:          true,
+		CompressChunkSize: 4,
+		ManualCleanup:     true,
+	}
+	defer l.Close()
+
+	// a directory in the way of the compressed backup makes compressing fail
+	name := backupFile(dir)
+	data := []byte("boo!boo!boo!")
+	isNil(ioutil.WriteFile(name, data, 0644), t)
+	isNil(os.Mkdir(name+compressSuffix, 0755), t)
+
+	// the error from the filesystem can still be told apart.
+	err := l.CleanNow()
+	var pathErr *os.PathError
+	assert(errors.As(err, &pathErr), t, "expected a *os.PathError, got %v", err)
+	assert(errors.Is(err, ErrCompressFailed), t, "expected ErrCompressFailed, got %v", err)
+	existsWithContent(name, data, t)
+	equals(0, l.Stats().LastMill.Compressed, t)
+}
+
+// gunzipMembers returns the decompressed contents of the gzip file name,
+// checking that it has want members.
+func gunzipMembers(name string, want int, t testing.TB) []byte {
+	f, err := os.Open(name)
+	isNilUp(err, t, 1)
+	defer f.Close()
+	r := bufio.NewReader(f)
+	gz, err := gzip.NewReader(r)
+	isNilUp(err, t, 1)
+	gz.Multistream(false)
+	var b []byte
+	members := 0
+	for {
+		p, err := ioutil.ReadAll(gz)
+		isNilUp(err, t, 1)
+		b = append(b, p...)
+		members++
+		if err := gz.Reset(r); err != nil {
+			break
+		}
+		gz.Multistream(false)
+	}
+	equalsUp(want, members, t, 1)
+	return b
+}
@@ -0,0 +1,41 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+)
+
+// ErrSameFile is wrapped by the error returned by Clone when the new Logger
+// would write to the same log file as the old one.
+var ErrSameFile = errors.New("log file is already in use by the Logger")
+
+// Clone returns a new Logger that writes to filename with the same options as
+// l, for spinning up log files per job or per connection that all follow the
+// same policy.  Every exported field is copied, so the hooks, Metrics and
+// Labels are shared; FallbackFilename and UploadStateFile are copied as they
+// are too, and should be changed before the clone is used if it shouldn't
+// share them.  The clone has its own file, backups and cleanup, and starts
+// them the first time it is written to, just as a new Logger would.  An
+// error is returned if filename isn't a valid name for a log file here, or
+// is the file that l writes to.
+func (l *Logger) Clone(filename string) (*Logger, error) {
+	c := &Logger{}
+	src := reflect.ValueOf(l).Elem()
+	dst := reflect.ValueOf(c).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		if src.Type().Field(i).IsExported() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	c.Filename = filename
+	name := c.primaryFilename()
+	if err := validateName(name, runtime.GOOS, c.backupNameExtra()); err != nil {
+		return nil, err
+	}
+	if name == l.primaryFilename() {
+		return nil, fmt.Errorf("%w: %s", ErrSameFile, name)
+	}
+	return c, nil
+}
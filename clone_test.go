@@ -0,0 +1,54 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestClone", t)
+	defer os.RemoveAll(dir)
+
+	var rotated []string
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxSize:    10,
+		MaxBackups: 1,
+		Labels:     map[string]string{"service": "api"},
+		OnRotate:   func(backup string, _ RotateReason) { rotated = append(rotated, backup) },
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	name := filepath.Join(dir, "job.log")
+	c, err := l.Clone(name)
+	isNil(err, t)
+	defer c.Close()
+	equals(name, c.Filename, t)
+	equals(10, c.MaxSize, t)
+	equals(1, c.MaxBackups, t)
+	equals(l.Labels, c.Labels, t)
+	assert(c.file == nil, t, "expected the clone to have no file open")
+
+	b := []byte("boo!boo!")
+	_, err = c.Write(b)
+	isNil(err, t)
+	existsWithContent(name, b, t)
+	existsWithContent(logFile(dir), []byte("boo!"), t)
+
+	// the clone rotates by the same policy, calling the same hooks.
+	newFakeTime()
+	_, err = c.Write(b)
+	isNil(err, t)
+	equals([]string{filepath.Join(dir, "job-"+fakeTime().UTC().Format(backupTimeFormat)+".log")}, rotated, t)
+
+	_, err = l.Clone(logFile(dir))
+	assert(errors.Is(err, ErrSameFile), t, "expected ErrSameFile, got %v", err)
+	_, err = l.Clone("foo\x00.log")
+	assert(errors.Is(err, ErrInvalidName), t, "expected ErrInvalidName, got %v", err)
+}
@@ -0,0 +1,269 @@
+package lumberjack
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// codec is a format that backups can be compressed in.
+type codec struct {
+	// ext is the suffix added to the names of backups in this format.
+	ext string
+
+	// compress writes src, the contents of the backup described by info, to
+	// dst in this format.  If keep is set, the backup's name and
+	// modification time are recorded too, where the format allows.
+	compress func(dst io.Writer, src io.Reader, info os.FileInfo, keep bool) error
+
+	// decompress returns a reader of the contents of f, which is in this
+	// format.  Closing it doesn't close f.
+	decompress func(f *os.File) (io.ReadCloser, error)
+}
+
+// Format is a compression format for backups, which can be added to the
+// built-in ones with RegisterFormat.
+type Format struct {
+	// Ext is the suffix added to the names of backups in this format, such
+	// as ".br".
+	Ext string
+
+	// NewWriter returns a writer that compresses what is written to it and
+	// writes the result to w.  Closing it must flush everything to w, but
+	// not close w.
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a reader of the decompressed contents of r.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// RegisterFormat makes f available as the CompressFormat name, and makes
+// backups in it recognized for retention and reading, for formats such as
+// brotli that would otherwise make lumberjack depend on a package for them.
+// It is meant to be called from an init function, and panics if name is
+// already registered, or if f.Ext is empty or already used by another
+// format.  An Ext may end with another's, as ".tar.gz" does with ".gz", in
+// which case a backup is taken to be in the format with the longer one.
+func RegisterFormat(name string, f Format) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[name]; ok {
+		panic("lumberjack: compression format " + name + " registered twice")
+	}
+	if f.Ext == "" {
+		panic("lumberjack: compression format " + name + " has no Ext")
+	}
+	for other, c := range codecs {
+		if c.ext == f.Ext {
+			panic("lumberjack: compression format " + name + " uses the Ext " + f.Ext + " of " + other)
+		}
+	}
+	codecs[name] = &codec{
+		ext: f.Ext,
+		compress: func(dst io.Writer, src io.Reader, _ os.FileInfo, _ bool) error {
+			w, err := f.NewWriter(dst)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, src); err != nil {
+				w.Close()
+				return err
+			}
+			return w.Close()
+		},
+		decompress: func(file *os.File) (io.ReadCloser, error) {
+			return f.NewReader(file)
+		},
+	}
+}
+
+// codecsMu guards codecs, which RegisterFormat adds to.
+var codecsMu sync.RWMutex
+
+// codecs are the supported compression formats, by the names used for
+// CompressFormat.  Backups in any of them are recognized, whatever
+// CompressFormat is set to.
+var codecs = map[string]*codec{
+	"gzip": {
+		ext: compressSuffix,
+		compress: func(dst io.Writer, src io.Reader, info os.FileInfo, keep bool) error {
+			gz := gzip.NewWriter(dst)
+			if keep {
+				gz.Name = info.Name()
+				gz.ModTime = info.ModTime()
+			}
+			if _, err := io.Copy(gz, src); err != nil {
+				return err
+			}
+			return gz.Close()
+		},
+		decompress: func(f *os.File) (io.ReadCloser, error) {
+			return gzip.NewReader(f)
+		},
+	},
+	"zip": {
+		ext: ".zip",
+		compress: func(dst io.Writer, src io.Reader, info os.FileInfo, keep bool) error {
+			zw := zip.NewWriter(dst)
+			h := &zip.FileHeader{Name: info.Name(), Method: zip.Deflate}
+			if keep {
+				h.Modified = info.ModTime()
+			}
+			w, err := zw.CreateHeader(h)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, src); err != nil {
+				return err
+			}
+			return zw.Close()
+		},
+		decompress: func(f *os.File) (io.ReadCloser, error) {
+			info, err := f.Stat()
+			if err != nil {
+				return nil, err
+			}
+			zr, err := zip.NewReader(f, info.Size())
+			if err != nil {
+				return nil, err
+			}
+			if len(zr.File) != 1 {
+				return nil, fmt.Errorf("zip archive has %d files, expected 1", len(zr.File))
+			}
+			return zr.File[0].Open()
+		},
+	},
+}
+
+// compressSource returns r, the backup being compressed, to be read through
+// a buffer of CompressBufferSize, if it is set.
+func (l *Logger) compressSource(r io.Reader) io.Reader {
+	if l.CompressBufferSize <= 0 {
+		return r
+	}
+	return &bufferedSource{r: r, size: l.CompressBufferSize}
+}
+
+// bufferedSource is a reader that, when io.Copy is used to copy from it,
+// as the codecs do, copies through a buffer of the given size, rather than
+// the default.
+type bufferedSource struct {
+	r    io.Reader
+	size int
+}
+
+func (b *bufferedSource) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+// WriteTo implements io.WriterTo, which io.Copy prefers.  r is wrapped so
+// that its own WriteTo, if it has one, isn't used instead of the buffer.
+func (b *bufferedSource) WriteTo(w io.Writer) (int64, error) {
+	return io.CopyBuffer(w, struct{ io.Reader }{b.r}, make([]byte, b.size))
+}
+
+// codec returns the codec that CompressFormat asks for.
+func (l *Logger) codec() (*codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if l.CompressFormat == "" {
+		return codecs["gzip"], nil
+	}
+	c, ok := codecs[l.CompressFormat]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression format %q", l.CompressFormat)
+	}
+	return c, nil
+}
+
+// codecFor returns the codec that the backup name was compressed with, or nil
+// if it isn't compressed.  If more than one codec's suffix matches, the one
+// with the longest suffix is used.
+func codecFor(name string) *codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	var found *codec
+	for _, c := range codecs {
+		if strings.HasSuffix(name, c.ext) && (found == nil || len(c.ext) > len(found.ext)) {
+			found = c
+		}
+	}
+	return found
+}
+
+// compresses reports whether a backup of size bytes is to be compressed by
+// the mill.
+func (l *Logger) compresses(size int64) bool {
+	return l.Compress && !l.CompressActive && size >= l.CompressMinSize
+}
+
+// isCompressed reports whether name is the name of a compressed backup.
+func isCompressed(name string) bool {
+	return codecFor(name) != nil
+}
+
+// trimCompressExt returns name without any compression suffix.
+func trimCompressExt(name string) string {
+	if c := codecFor(name); c != nil {
+		return strings.TrimSuffix(name, c.ext)
+	}
+	return name
+}
+
+// compressedVersion returns the name of the compressed version of the backup
+// name, or "" if there isn't one.  If there is more than one, the one with
+// the longest suffix is returned, so that the answer doesn't depend on the
+// order codecs are looked at in.
+func compressedVersion(name string) string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	found := ""
+	for _, c := range codecs {
+		if len(c.ext) <= len(found)-len(name) {
+			continue
+		}
+		if _, err := os.Stat(name + c.ext); err == nil {
+			found = name + c.ext
+		}
+	}
+	return found
+}
+
+// openBackup opens the named backup for reading, decompressing it if it is
+// compressed.
+func openBackup(name string) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	c := codecFor(name)
+	if c == nil {
+		return f, nil
+	}
+	r, err := c.decompress(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("can't read compressed log file %s: %w", name, err)
+	}
+	return &decompressReader{r, f}, nil
+}
+
+// decompressReader reads decompressed data, closing both the decompressor
+// and the underlying file when it is closed.
+type decompressReader struct {
+	io.ReadCloser
+	f *os.File
+}
+
+// Close implements io.Closer.
+func (d *decompressReader) Close() error {
+	err := d.ReadCloser.Close()
+	if ferr := d.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
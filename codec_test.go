@@ -0,0 +1,268 @@
+package lumberjack
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// zipContent returns the contents of the single file in the named zip archive.
+func zipContent(name string, t testing.TB) []byte {
+	zr, err := zip.OpenReader(name)
+	isNilUp(err, t, 1)
+	defer zr.Close()
+	equalsUp(1, len(zr.File), t, 1)
+	r, err := zr.File[0].Open()
+	isNilUp(err, t, 1)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	isNilUp(err, t, 1)
+	return b
+}
+
+func TestCompressZip(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressZip", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		MaxSize:        10,
+		MaxBackups:     1,
+		Compress:       true,
+		CompressFormat: "zip",
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+
+	// an interrupted compression is redone.
+	first := backupFile(dir)
+	err := ioutil.WriteFile(first, []byte("foo!"), 0644)
+	isNil(err, t)
+	err = ioutil.WriteFile(first+".zip", []byte{}, 0644)
+	isNil(err, t)
+
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.CleanNow(), t)
+	notExist(first, t)
+	equals("foo!", string(zipContent(first+".zip", t)), t)
+
+	// the history reads through zipped backups.
+	r, err := l.OpenHistory()
+	isNil(err, t)
+	all, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("foo!boo!", string(all), t)
+
+	// a zipped backup counts toward MaxBackups, just as a gzipped one does.
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+	isNil(l.CleanNow(), t)
+	notExist(first+".zip", t)
+	equals("boo!", string(zipContent(backupFile(dir)+".zip", t)), t)
+	fileCount(dir, 2, t)
+}
+
+func TestCompressUnknownFormat(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressUnknownFormat", t)
+	defer os.RemoveAll(dir)
+
+	err := ioutil.WriteFile(backupFile(dir), []byte("foo!"), 0644)
+	isNil(err, t)
+	l := &Logger{
+		Filename:       logFile(dir),
+		Compress:       true,
+		CompressFormat: "rar",
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+	notNil(l.CleanNow(), t)
+	exists(backupFile(dir), t)
+}
+
+func TestCompressDeterministic(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressDeterministic", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		ManualCleanup: true,
+	}
+
+	// the same content, rotated and modified at different times.
+	var names []string
+	for i := 0; i < 2; i++ {
+		newFakeTime()
+		name := backupFile(dir)
+		err := ioutil.WriteFile(name, []byte("boo!"), 0644)
+		isNil(err, t)
+		mtime := fakeCurrentTime.Add(time.Duration(i) * time.Hour)
+		isNil(os.Chtimes(name, mtime, mtime), t)
+		names = append(names, name+compressSuffix)
+	}
+	isNil(l.CleanNow(), t)
+
+	first, err := ioutil.ReadFile(names[0])
+	isNil(err, t)
+	second, err := ioutil.ReadFile(names[1])
+	isNil(err, t)
+	assert(bytes.Equal(first, second), t, "backups of the same content differ")
+}
+
+func TestCompressKeepName(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressKeepName", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:         logFile(dir),
+		Compress:         true,
+		CompressKeepName: true,
+		ManualCleanup:    true,
+	}
+	newFakeTime()
+	name := backupFile(dir)
+	err := ioutil.WriteFile(name, []byte("boo!"), 0644)
+	isNil(err, t)
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	isNil(os.Chtimes(name, mtime, mtime), t)
+	isNil(l.CleanNow(), t)
+
+	f, err := os.Open(name + compressSuffix)
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	equals(filepath.Base(name), gz.Name, t)
+	assert(mtime.Equal(gz.ModTime), t, "expected mod time %v, got %v", mtime, gz.ModTime)
+}
+
+// chunkWriter records the sizes of the writes made to it.
+type chunkWriter struct {
+	buf   bytes.Buffer
+	sizes []int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return w.buf.Write(p)
+}
+
+func TestCompressBufferSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressBufferSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:           logFile(dir),
+		Compress:           true,
+		CompressBufferSize: 7,
+		ManualCleanup:      true,
+	}
+	defer l.Close()
+
+	data := bytes.Repeat([]byte("boo!"), 10)
+	w := &chunkWriter{}
+	_, err := io.Copy(w, l.compressSource(bytes.NewReader(data)))
+	isNil(err, t)
+	equals(data, w.buf.Bytes(), t)
+	equals([]int{7, 7, 7, 7, 7, 5}, w.sizes, t)
+
+	name := backupFile(dir)
+	isNil(ioutil.WriteFile(name, data, 0644), t)
+	isNil(l.CleanNow(), t)
+	notExist(name, t)
+	f, err := os.Open(name + compressSuffix)
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	b, err := ioutil.ReadAll(gz)
+	isNil(err, t)
+	equals(data, b, t)
+}
+
+func TestSyncCompressed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSyncCompressed", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:       logFile(dir),
+		Compress:       true,
+		SyncCompressed: true,
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+
+	name := backupFile(dir)
+	data := []byte("boo!")
+	isNil(ioutil.WriteFile(name, data, 0644), t)
+	isNil(l.CleanNow(), t)
+	notExist(name, t)
+	f, err := os.Open(name + compressSuffix)
+	isNil(err, t)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	isNil(err, t)
+	b, err := ioutil.ReadAll(gz)
+	isNil(err, t)
+	equals(data, b, t)
+}
+
+func TestRegisterFormatExt(t *testing.T) {
+	gz := Format{
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+		NewReader: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	}
+	registers := func(name, ext string) (ok bool) {
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		f := gz
+		f.Ext = ext
+		RegisterFormat(name, f)
+		return true
+	}
+	defer func() {
+		codecsMu.Lock()
+		delete(codecs, "targz")
+		codecsMu.Unlock()
+	}()
+
+	assert(!registers("empty", ""), t, "expected an empty Ext to panic")
+	assert(!registers("gzip2", ".gz"), t, "expected a duplicate Ext to panic")
+	assert(registers("targz", ".tar.gz"), t, "expected an Ext ending with another to be allowed")
+
+	// the longest matching suffix wins, whatever order the codecs are in.
+	for i := 0; i < 10; i++ {
+		equals(".tar.gz", codecFor("foo.log.tar.gz").ext, t)
+		equals(compressSuffix, codecFor("foo.log.gz").ext, t)
+	}
+}
@@ -0,0 +1,14 @@
+// +build !plan9
+
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice reports whether err was caused by renaming a file to another
+// filesystem.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,14 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+)
+
+// isCrossDevice reports whether err may have been caused by renaming a file
+// to another filesystem, which plan9 doesn't tell apart from other rename
+// failures.
+func isCrossDevice(err error) bool {
+	var le *os.LinkError
+	return errors.As(err, &le)
+}
@@ -0,0 +1,93 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// dailyBoundary returns the first instant on the day y-m-d in loc at which
+// the wall clock reads at after midnight, or, if the clock skips over that
+// time that day, the instant it skips over it.  If the clock goes back over
+// that time, it is the first of the two.
+func dailyBoundary(y int, m time.Month, d int, at time.Duration, loc *time.Location) time.Time {
+	// wall clock readings are compared as if they were times in UTC.
+	target := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Add(at)
+	wall := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	}
+
+	var first time.Time
+	consider := func(t time.Time) {
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+	}
+	// no zone is more than a day from UTC, so this covers every zone in
+	// effect at some point of the day.
+	end := target.Add(36 * time.Hour)
+	for t := target.Add(-36 * time.Hour); t.Before(end); {
+		_, offset := t.In(loc).Zone()
+		if u := target.Add(-time.Duration(offset) * time.Second); wall(u).Equal(target) {
+			consider(u)
+		}
+		start, next := t.In(loc).ZoneBounds()
+		if !start.IsZero() && wall(start.Add(-1)).Before(target) && wall(start).After(target) {
+			consider(start)
+		}
+		if next.IsZero() {
+			break
+		}
+		t = next
+	}
+	return first
+}
+
+// dailyErr returns the error loading TimeZone, if RotateDaily is set and it
+// can't be loaded, since there'd be no telling when to rotate.
+func (l *Logger) dailyErr() error {
+	if !l.RotateDaily {
+		return nil
+	}
+	_, err := l.location()
+	return err
+}
+
+// dailyDue reports whether RotateDaily calls for the log file to be rotated
+// now, working out when next to rotate if it hasn't yet for this file.  A log
+// file that was already there is rotated if it hasn't been since it was
+// last written to.  It returns an error if the time to rotate can't be
+// worked out.
+func (l *Logger) dailyDue() (bool, error) {
+	if !l.RotateDaily || l.file == nil || l.unused || l.passthrough {
+		return false, nil
+	}
+	if l.dailyNext.IsZero() {
+		if err := l.scheduleDaily(l.dailyFrom); err != nil {
+			return false, err
+		}
+	}
+	return !currentTime().Before(l.dailyNext), nil
+}
+
+// scheduleDaily sets dailyNext to the first time of day to rotate at after
+// from, but never on a day that has already had its rotation, so that a
+// clock that is set back can't cause a second rotation.
+func (l *Logger) scheduleDaily(from time.Time) error {
+	loc, err := l.location()
+	if err != nil {
+		return err
+	}
+	y, m, d := from.In(loc).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	if !day.After(l.dailyDone) {
+		day = l.dailyDone.AddDate(0, 0, 1)
+	}
+	for {
+		next := dailyBoundary(day.Year(), day.Month(), day.Day(), l.RotateDailyAt, loc)
+		if next.After(from) {
+			l.dailyNext, l.dailyDay = next, day
+			return nil
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,138 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDailyBoundary(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+	santiago, err := time.LoadLocation("America/Santiago")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+	tests := []struct {
+		name string
+		loc  *time.Location
+		day  time.Time
+		at   time.Duration
+		want time.Time
+	}{
+		{"ordinary day", ny, time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC), 0,
+			time.Date(2024, 3, 9, 5, 0, 0, 0, time.UTC)},
+		{"midnight on spring forward", ny, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 0,
+			time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC)},
+		// 02:30 doesn't happen, so it's when the clock jumps to 03:00 EDT.
+		{"skipped time", ny, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 150 * time.Minute,
+			time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC)},
+		{"after spring forward", ny, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 4 * time.Hour,
+			time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)},
+		// 01:30 happens twice, first in EDT.
+		{"repeated time", ny, time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC), 90 * time.Minute,
+			time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC)},
+		{"after fall back", ny, time.Date(2024, 11, 3, 0, 0, 0, 0, time.UTC), 4 * time.Hour,
+			time.Date(2024, 11, 3, 9, 0, 0, 0, time.UTC)},
+		// Chile skips from 24:00 to 01:00, so the day starts at 01:00.
+		{"skipped midnight", santiago, time.Date(2024, 9, 8, 0, 0, 0, 0, time.UTC), 0,
+			time.Date(2024, 9, 8, 4, 0, 0, 0, time.UTC)},
+		{"utc", time.UTC, time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), 90 * time.Minute,
+			time.Date(2024, 3, 10, 1, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got := dailyBoundary(tt.day.Year(), tt.day.Month(), tt.day.Day(), tt.at, tt.loc)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got.UTC(), tt.want)
+		}
+	}
+}
+
+func TestRotateDaily(t *testing.T) {
+	_, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+	now := time.Date(2024, 11, 2, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestRotateDaily", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		RotateDaily:   true,
+		RotateDailyAt: 90 * time.Minute,
+		TimeZone:      "America/New_York",
+		ReopenOnWrite: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	writeAt := func(t2 time.Time) {
+		now = t2
+		_, err := l.Write(b)
+		isNil(err, t)
+	}
+	writeAt(now)
+	// 01:00 EDT.
+	writeAt(time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC))
+	fileCount(dir, 1, t)
+	// 01:30 EDT.
+	writeAt(time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC))
+	fileCount(dir, 2, t)
+	// 01:30 EST, the second time that day, doesn't rotate again.
+	writeAt(time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC))
+	fileCount(dir, 2, t)
+	// nor does setting the clock back.
+	writeAt(time.Date(2024, 11, 3, 5, 45, 0, 0, time.UTC))
+	fileCount(dir, 2, t)
+	// 01:30 EST the next day.
+	writeAt(time.Date(2024, 11, 4, 6, 0, 0, 0, time.UTC))
+	fileCount(dir, 2, t)
+	writeAt(time.Date(2024, 11, 4, 6, 30, 0, 0, time.UTC))
+	fileCount(dir, 3, t)
+	// a day with no writes just means no rotation that day.
+	writeAt(time.Date(2024, 11, 6, 7, 0, 0, 0, time.UTC))
+	fileCount(dir, 4, t)
+
+	// a log file last written to before the last rotation time is rotated
+	// by the first write to it.
+	isNil(l.Close(), t)
+	yesterday := time.Date(2024, 11, 5, 12, 0, 0, 0, time.UTC)
+	isNil(os.Chtimes(logFile(dir), yesterday, yesterday), t)
+	writeAt(time.Date(2024, 11, 6, 8, 0, 0, 0, time.UTC))
+	fileCount(dir, 5, t)
+}
+
+func TestRotateDailyBadTimeZone(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRotateDailyBadTimeZone", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:    logFile(dir),
+		RotateDaily: true,
+		TimeZone:    "Not/AZone",
+	}
+	defer l.Close()
+
+	// there's no telling when to rotate, so writes fail rather than never
+	// rotating.
+	_, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+	notExist(logFile(dir), t)
+
+	// a file that's already open fails the same way.
+	l.TimeZone = ""
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	l.TimeZone = "Not/AZone"
+	l.dailyNext = time.Time{}
+	_, err = l.Write([]byte("boo!"))
+	notNil(err, t)
+	existsWithContent(logFile(dir), []byte("boo!"), t)
+}
@@ -0,0 +1,99 @@
+package lumberjack
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// ErrWriteTimeout is returned by Write when it takes longer than WriteTimeout,
+// or when an earlier write that did so is still stuck.  It matches
+// os.ErrDeadlineExceeded with errors.Is.
+var ErrWriteTimeout = fmt.Errorf("write timed out: %w", os.ErrDeadlineExceeded)
+
+// WriteContext is like Write, but gives up and returns ctx.Err() if ctx is
+// done before the write, including any rotation it causes, has finished.  As
+// with WriteTimeout, which also applies, a write that gives up before it gets
+// going is dropped, and one that is already under way carries on in the
+// background.  Writes with a ctx that can be done aren't batched.
+func (l *Logger) WriteContext(ctx context.Context, p []byte) (int, error) {
+	return l.instrumentWrite(p, func(p []byte) (int, error) {
+		return l.writeContext(ctx, p)
+	})
+}
+
+// writeContext does the work of WriteContext, and of Write, which batches
+// writes if BatchWindow is set and ctx can't be done.
+func (l *Logger) writeContext(ctx context.Context, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if ctx.Done() == nil {
+		if l.BatchWindow > 0 {
+			return l.writeBatched(p)
+		}
+		if l.WriteTimeout <= 0 {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return l.write(p)
+		}
+	}
+	return l.writeAsync(ctx, p)
+}
+
+// writeAsync writes p on another goroutine, giving up when ctx is done or
+// after WriteTimeout.
+func (l *Logger) writeAsync(ctx context.Context, p []byte) (int, error) {
+	if atomic.LoadInt32(&l.stalled) == 1 {
+		return 0, ErrWriteTimeout
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	// the write may outlive this call, and the caller is free to reuse p as
+	// soon as we return.
+	b := append([]byte(nil), p...)
+	done := make(chan result, 1)
+	var gaveUp int32
+	go func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if atomic.LoadInt32(&gaveUp) == 1 {
+			done <- result{}
+			return
+		}
+		n, err := l.write(b)
+		done <- result{n, err}
+	}()
+
+	var timeout <-chan time.Time
+	if l.WriteTimeout > 0 {
+		t := time.NewTimer(l.WriteTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	var err error
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-timeout:
+		err = ErrWriteTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	atomic.StoreInt32(&gaveUp, 1)
+
+	// only one write gets to be left behind, and it clears the way for the
+	// others once it's done.
+	if atomic.CompareAndSwapInt32(&l.stalled, 0, 1) {
+		go func() {
+			<-done
+			atomic.StoreInt32(&l.stalled, 0)
+		}()
+	}
+	return 0, err
+}
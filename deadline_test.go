@@ -0,0 +1,81 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestWriteTimeout(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteTimeout", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		WriteTimeout: 10 * time.Millisecond,
+	}
+	defer l.Close()
+
+	// holding the lock stands in for a hung filesystem.
+	l.mu.Lock()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	equals(0, n, t)
+	equals(ErrWriteTimeout, err, t)
+	assert(errors.Is(err, os.ErrDeadlineExceeded), t, "expected a deadline error, got %v", err)
+
+	// while that write is stuck, others fail straight away.
+	start := time.Now()
+	_, err = l.Write([]byte("lost"))
+	equals(ErrWriteTimeout, err, t)
+	assert(time.Since(start) < 10*time.Millisecond, t, "write should not have waited")
+
+	// the stuck write never got going, so it is dropped once things move
+	// again.
+	l.mu.Unlock()
+	<-time.After(10 * time.Millisecond)
+	n, err = l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(logFile(dir), b, t)
+}
+
+func TestWriteContext(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteContext", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.WriteContext(context.Background(), b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err = l.WriteContext(ctx, []byte("lost"))
+	equals(0, n, t)
+	equals(context.Canceled, err, t)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	l.mu.Lock()
+	n, err = l.WriteContext(ctx, []byte("lost"))
+	l.mu.Unlock()
+	equals(0, n, t)
+	equals(context.DeadlineExceeded, err, t)
+
+	<-time.After(10 * time.Millisecond)
+	b2 := []byte("foo!")
+	n, err = l.WriteContext(context.Background(), b2)
+	isNil(err, t)
+	equals(len(b2), n, t)
+	existsWithContent(logFile(dir), append(b, b2...), t)
+}
@@ -0,0 +1,15 @@
+// +build !linux,!darwin,!freebsd,!windows
+
+package lumberjack
+
+import (
+	"errors"
+)
+
+// diskSpace returns the bytes available to us and the total size of the
+// filesystem holding dir.  It isn't supported on this platform.
+func diskSpace(_ string) (free, total uint64, err error) {
+	return 0, 0, errNoDiskSpace
+}
+
+var errNoDiskSpace = errors.New("disk space is not available on this platform")
@@ -0,0 +1,18 @@
+// +build linux darwin freebsd
+
+package lumberjack
+
+import (
+	"syscall"
+)
+
+// diskSpace returns the bytes available to us and the total size of the
+// filesystem holding dir.
+func diskSpace(dir string) (free, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	return uint64(st.Bavail) * bsize, uint64(st.Blocks) * bsize, nil
+}
@@ -0,0 +1,55 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnsetEnv is wrapped by the error returned when ExpandEnv is set and
+// Filename refers to an environment variable that isn't set.
+var ErrUnsetEnv = errors.New("environment variable in log file name is not set")
+
+// expandEnv replaces ${VAR} and $VAR in name with the values of the
+// environment variables they refer to, all of which must be set, though they
+// may be empty.
+func expandEnv(name string) (string, error) {
+	var unset []string
+	expanded := os.Expand(name, func(key string) string {
+		v, ok := os.LookupEnv(key)
+		if !ok {
+			unset = append(unset, key)
+		}
+		return v
+	})
+	if len(unset) > 0 {
+		return "", fmt.Errorf("%w: %s in %q", ErrUnsetEnv, strings.Join(unset, ", "), name)
+	}
+	return expanded, nil
+}
+
+// expanded returns name with environment variables expanded if ExpandEnv is
+// set and they can be, or as it is otherwise.
+func (l *Logger) expanded(name string) string {
+	if l.ExpandEnv {
+		if expanded, err := expandEnv(name); err == nil {
+			return expanded
+		}
+	}
+	return name
+}
+
+// filenameErr returns the error from expanding environment variables in the
+// name of the log file in use, if ExpandEnv is set and it can't be done.
+func (l *Logger) filenameErr() error {
+	if !l.ExpandEnv {
+		return nil
+	}
+	name := l.Filename
+	if l.fallbackActive() {
+		name = l.FallbackFilename
+	}
+	_, err := expandEnv(name)
+	return err
+}
@@ -0,0 +1,56 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestExpandEnv", t)
+	defer os.RemoveAll(dir)
+
+	t.Setenv("LUMBERJACK_TEST_DIR", dir)
+	t.Setenv("LUMBERJACK_TEST_SERVICE", "foobar")
+
+	l := &Logger{
+		Filename:  "${LUMBERJACK_TEST_DIR}/$LUMBERJACK_TEST_SERVICE.log",
+		ExpandEnv: true,
+	}
+	defer l.Close()
+	isNil(l.Preflight(), t)
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(filepath.Join(dir, "foobar.log"), b, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(filepath.Join(dir, "foobar-"+fakeTime().UTC().Format(backupTimeFormat)+".log"), b, t)
+}
+
+func TestExpandEnvUnset(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestExpandEnvUnset", t)
+	defer os.RemoveAll(dir)
+
+	os.Unsetenv("LUMBERJACK_TEST_UNSET")
+	l := &Logger{
+		Filename:  filepath.Join(dir, "${LUMBERJACK_TEST_UNSET}", "foobar.log"),
+		ExpandEnv: true,
+	}
+	defer l.Close()
+	err := l.Preflight()
+	assert(errors.Is(err, ErrUnsetEnv), t, "expected ErrUnsetEnv, got %v", err)
+	_, err = l.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrUnsetEnv), t, "expected ErrUnsetEnv, got %v", err)
+	fileCount(dir, 0, t)
+
+	// without ExpandEnv, the name is used as it is.
+	l.ExpandEnv = false
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	exists(l.Filename, t)
+}
@@ -0,0 +1,55 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrOpenFailed is wrapped by errors from opening or creating a log
+	// file.
+	ErrOpenFailed = errors.New("can't open log file")
+
+	// ErrRotateFailed is wrapped by errors from moving the log file aside to
+	// become a backup.
+	ErrRotateFailed = errors.New("can't rotate log file")
+
+	// ErrCompressFailed is wrapped by errors from compressing a backup.
+	ErrCompressFailed = errors.New("can't compress backup")
+
+	// ErrRemoveFailed is wrapped by errors from removing an old backup.
+	ErrRemoveFailed = errors.New("can't remove backup")
+
+	// ErrUploadFailed is wrapped by errors from uploading a backup.
+	ErrUploadFailed = errors.New("can't upload backup")
+)
+
+// ErrClosed is returned by Write, Rotate and Adopt after Close, unless
+// ReopenOnWrite is set.  It wraps os.ErrClosed.
+var ErrClosed = fmt.Errorf("logger is closed: %w", os.ErrClosed)
+
+// opError is an error from an operation of the kind that one of the errors
+// above stands for.  It reads the same as the error it wraps, so that adding
+// the kind doesn't change any messages.
+type opError struct {
+	kind error
+	err  error
+}
+
+// failed returns err marked as being of the given kind, so that errors.Is
+// reports it as that, or nil if err is nil.
+func failed(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &opError{kind: kind, err: err}
+}
+
+func (e *opError) Error() string {
+	return e.err.Error()
+}
+
+func (e *opError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
@@ -0,0 +1,45 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailed(t *testing.T) {
+	isNil(failed(ErrRemoveFailed, nil), t)
+
+	cause := errors.New("boom")
+	err := failed(ErrRemoveFailed, cause)
+	equals("boom", err.Error(), t)
+	assert(errors.Is(err, ErrRemoveFailed), t, "expected ErrRemoveFailed")
+	assert(errors.Is(err, cause), t, "expected the cause")
+	assert(!errors.Is(err, ErrCompressFailed), t, "unexpected ErrCompressFailed")
+}
+
+func TestErrorKinds(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestErrorKinds", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Rename:   func(string, string) error { return errors.New("nope") },
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	err = l.Rotate()
+	assert(errors.Is(err, ErrRotateFailed), t, "expected ErrRotateFailed, got %v", err)
+	equals("can't rename log file: nope", err.Error(), t)
+
+	blocker := filepath.Join(dir, "blocker")
+	isNil(ioutil.WriteFile(blocker, []byte("not a dir"), 0644), t)
+	l2 := &Logger{Filename: filepath.Join(blocker, "foobar.log")}
+	defer l2.Close()
+	_, err = l2.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrOpenFailed), t, "expected ErrOpenFailed, got %v", err)
+}
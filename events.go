@@ -0,0 +1,262 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType identifies the kind of thing an Event reports.
+type EventType int
+
+const (
+	// EventFailover is reported when the Logger gives up on Filename and
+	// starts writing to FallbackFilename.
+	EventFailover EventType = iota + 1
+
+	// EventFailback is reported when the Logger has switched from
+	// FallbackFilename back to Filename.
+	EventFailback
+
+	// EventPurge is reported when a backup is removed to free up space after
+	// a write failed because the disk was full.
+	EventPurge
+
+	// EventUpload is reported when a backup has been uploaded, or with Err
+	// set when uploading it failed.
+	EventUpload
+
+	// EventRotate is reported when the log file has been moved aside to
+	// become a backup.
+	EventRotate
+
+	// EventCompress is reported when a backup has been compressed, or with
+	// Err set when compressing it failed.
+	EventCompress
+
+	// EventRemove is reported when an old backup has been removed, or with
+	// Err set when removing it failed.
+	EventRemove
+
+	// EventReconcile is reported when the log file on disk doesn't match what
+	// the Logger expected, because something else has changed it.  Err
+	// describes the difference.
+	EventReconcile
+
+	// EventStderr is reported when the Logger starts writing to stderr
+	// because StderrFallback is set and no file could be written.  Err is
+	// the error that caused it.
+	EventStderr
+
+	// EventBuffer is reported when the Logger starts holding writes in memory
+	// because MemoryBuffer is set and no file could be written.  Err is the
+	// error that caused it.
+	EventBuffer
+
+	// EventReplay is reported when the writes held in memory have been
+	// written to the log file.  Size is how many bytes were written, and Err,
+	// if set, wraps ErrBufferOverflow and says how many were lost.
+	EventReplay
+
+	// EventPressure is reported when DiskPressure finds the disk too full,
+	// before it takes the next step to make room.  Step is the step, and Err
+	// says how full the disk is.
+	EventPressure
+
+	// EventLink is reported with Err set when a backup couldn't be linked
+	// into LinkDir.  Filename is the backup.
+	EventLink
+
+	// EventRenameUnchecked is reported, once per Logger, when the log file
+	// had to be moved to its backup name by checking that the name was free
+	// and then renaming, because the platform or filesystem can't do both in
+	// one step.  In between, a backup that appeared under that name could be
+	// replaced.  Filename is the backup, and Err says why.
+	EventRenameUnchecked
+)
+
+// String returns a short lowercase name for the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventFailover:
+		return "failover"
+	case EventFailback:
+		return "failback"
+	case EventPurge:
+		return "purge"
+	case EventUpload:
+		return "upload"
+	case EventRotate:
+		return "rotate"
+	case EventCompress:
+		return "compress"
+	case EventRemove:
+		return "remove"
+	case EventReconcile:
+		return "reconcile"
+	case EventStderr:
+		return "stderr"
+	case EventBuffer:
+		return "buffer"
+	case EventReplay:
+		return "replay"
+	case EventPressure:
+		return "pressure"
+	case EventLink:
+		return "link"
+	case EventRenameUnchecked:
+		return "rename-unchecked"
+	}
+	return "unknown"
+}
+
+// RotateReason says why the log file was rotated.
+type RotateReason int
+
+const (
+	// RotateSize means a write would have taken the log file over MaxSize.
+	RotateSize RotateReason = iota + 1
+
+	// RotateSchedule means RotateInterval or RotateDaily called for it.
+	RotateSchedule
+
+	// RotateManual means Rotate was called.
+	RotateManual
+
+	// RotateStartup means the log file was already too big to write to, or
+	// couldn't be appended to, when the Logger opened it.
+	RotateStartup
+
+	// RotatePressure means DiskPressure found the disk too full, and the
+	// backup was removed straight away.
+	RotatePressure
+
+	// RotateWrites means the log file had had MaxWrites writes.
+	RotateWrites
+)
+
+// String returns a short lowercase name for the reason.
+func (r RotateReason) String() string {
+	switch r {
+	case RotateSize:
+		return "size"
+	case RotateSchedule:
+		return "schedule"
+	case RotateManual:
+		return "manual"
+	case RotateStartup:
+		return "startup"
+	case RotatePressure:
+		return "pressure"
+	case RotateWrites:
+		return "writes"
+	}
+	return "unknown"
+}
+
+// Event describes something notable that the Logger did with its files.
+type Event struct {
+	// Type is the kind of event.
+	Type EventType
+
+	// Time is when the event happened.
+	Time time.Time
+
+	// Filename is the file the event concerns.  For failover and failback
+	// events, it is the file that writes are now going to.  For rotate and
+	// compress events, it is the new backup file.
+	Filename string
+
+	// Source is the file that Filename was made from, for rotate and compress
+	// events.
+	Source string
+
+	// Size is the size of Filename in bytes, where known.
+	Size int64
+
+	// Duration is how long the operation took, for compress events.
+	Duration time.Duration
+
+	// Reason is why the log file was rotated, for rotate events.
+	Reason RotateReason
+
+	// Step is the step that DiskPressure is taking, for pressure events.
+	Step int
+
+	// Labels are the Logger's Labels.
+	Labels map[string]string
+
+	// Err is the error that caused the event, if any.
+	Err error
+}
+
+// MarshalJSON implements json.Marshaler, encoding the event as an object with
+// the fields "type", "time", "filename", "source", "size", "duration" (in
+// seconds), "reason", "step", "error" and "labels", the last seven only being
+// present when set.
+func (e Event) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Type     string            `json:"type"`
+		Time     time.Time         `json:"time"`
+		Filename string            `json:"filename"`
+		Source   string            `json:"source,omitempty"`
+		Size     int64             `json:"size,omitempty"`
+		Duration float64           `json:"duration,omitempty"`
+		Reason   string            `json:"reason,omitempty"`
+		Step     int               `json:"step,omitempty"`
+		Error    string            `json:"error,omitempty"`
+		Labels   map[string]string `json:"labels,omitempty"`
+	}{
+		Type:     e.Type.String(),
+		Time:     e.Time,
+		Filename: e.Filename,
+		Source:   e.Source,
+		Size:     e.Size,
+		Duration: e.Duration.Seconds(),
+		Step:     e.Step,
+		Labels:   e.Labels,
+	}
+	if e.Reason != 0 {
+		v.Reason = e.Reason.String()
+	}
+	if e.Err != nil {
+		v.Error = e.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// emit records e in the journal and Metrics and sends it to the OnEvent
+// callback and WebhookURL, if they are set.
+func (l *Logger) emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = currentTime()
+	}
+	if e.Labels == nil {
+		e.Labels = l.Labels
+	}
+	l.writeJournal(e)
+	l.recordEvent(e)
+	if l.WebhookURL != "" {
+		l.sendWebhook(e)
+	}
+	if l.OnEvent != nil {
+		l.OnEvent(e)
+	}
+}
+
+// emitRotate reports the rotation e with emit, and to OnRotate if it is set.
+func (l *Logger) emitRotate(e Event) {
+	l.emit(e)
+	if l.OnRotate != nil {
+		l.OnRotate(e.Filename, e.Reason)
+	}
+}
+
+// rotateReason returns why the rotation under way is happening.  A log file
+// is only moved aside outside of rotate when it couldn't be appended to on
+// opening.
+func (l *Logger) rotateReason() RotateReason {
+	if l.reason == 0 {
+		return RotateStartup
+	}
+	return l.reason
+}
@@ -1,6 +1,8 @@
 package lumberjack
 
 import (
+	"compress/zlib"
+	"io"
 	"log"
 )
 
@@ -15,3 +17,26 @@ func Example() {
 		Compress:   true, // disabled by default
 	})
 }
+
+// Other compression formats can be added with RegisterFormat, usually from an
+// init function.  This adds zlib; brotli, say, is added the same way, with
+// the writer and reader from a brotli package such as
+// github.com/andybalholm/brotli, so that lumberjack itself doesn't have to
+// depend on one.
+func ExampleRegisterFormat() {
+	RegisterFormat("zlib", Format{
+		Ext: ".zz",
+		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+			return zlib.NewWriter(w), nil
+		},
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	})
+
+	log.SetOutput(&Logger{
+		Filename:       "/var/log/myapp/foo.log",
+		Compress:       true,
+		CompressFormat: "zlib",
+	})
+}
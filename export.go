@@ -0,0 +1,190 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// exportManifest is the name of the manifest that ExportTo writes.
+const exportManifest = "manifest.json"
+
+// ExportManifest is the content of the manifest.json that ExportTo writes
+// alongside the files it exports.
+type ExportManifest struct {
+	// ExportedAt is when the export was made.
+	ExportedAt time.Time `json:"exported_at"`
+
+	// LogFile is the path of the log file the export was made from.
+	LogFile string `json:"log_file"`
+
+	// Files lists the exported files, the log file first, if there was one,
+	// and then the backups, newest first.
+	Files []ExportedFile `json:"files"`
+}
+
+// ExportedFile describes one file in an ExportManifest.
+type ExportedFile struct {
+	// Name is the file's name in the export directory.
+	Name string `json:"name"`
+
+	// Size is the size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the file.
+	SHA256 string `json:"sha256"`
+
+	// Current is whether the file is a snapshot of the log file, rather than
+	// a backup.
+	Current bool `json:"current,omitempty"`
+}
+
+// ExportTo gathers up the backups and a snapshot of the log file, as
+// OpenCurrent would read it, into the directory dir, along with a
+// manifest.json describing them, for collecting the logs to hand to someone
+// else.  Backups are hard linked where possible and copied otherwise.  The
+// export is put together under a temporary name next to dir and renamed into
+// place at the end, so dir either holds the whole export or doesn't exist.
+// It is an error if dir already exists.  The snapshot and the list of
+// backups are taken at the same moment, so that a rotation can't leave data
+// out of the export or in it twice.  Cleanup waits until the backups have
+// been gathered, but writes to the log file carry on.
+func (l *Logger) ExportTo(dir string) (err error) {
+	if _, err := os.Lstat(dir); err == nil {
+		return &os.PathError{Op: "export", Path: dir, Err: os.ErrExist}
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("can't make export directory: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	// the snapshot is taken and the backups listed together, holding off
+	// rotation and cleanup, so that nothing is rotated out from under the
+	// snapshot, or into the backups, in between.
+	l.flushBatch()
+	l.mu.Lock()
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	name := l.filename()
+	m := ExportManifest{ExportedAt: currentTime(), LogFile: name, Files: []ExportedFile{}}
+	var current io.ReadCloser
+	// a file that can't be rotated can't be read back either.
+	if !l.passingThrough() {
+		current, err = l.openCurrent()
+		if err != nil && !os.IsNotExist(err) {
+			l.mu.Unlock()
+			return err
+		}
+	}
+	files, err := l.oldLogFiles()
+	l.mu.Unlock()
+	if err != nil {
+		if current != nil {
+			current.Close()
+		}
+		return err
+	}
+
+	if current != nil {
+		f, err := exportFile(filepath.Join(tmp, strings.TrimSuffix(filepath.Base(name), compressSuffix)), current)
+		current.Close()
+		if err != nil {
+			return err
+		}
+		f.Current = true
+		m.Files = append(m.Files, f)
+	}
+
+	backups, err := l.exportBackups(tmp, files)
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, backups...)
+
+	b, err := json.MarshalIndent(m, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, exportManifest), b, 0644); err != nil {
+		return fmt.Errorf("can't write export manifest: %w", err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("can't move export into place: %w", err)
+	}
+	return nil
+}
+
+// exportBackups links or copies the backups files into dir.  It must be
+// called with l.millMu held, so that cleanup doesn't touch them meanwhile.
+func (l *Logger) exportBackups(dir string, files []logInfo) ([]ExportedFile, error) {
+	var exported []ExportedFile
+	for _, f := range files {
+		src := filepath.Join(l.dir(), f.Name())
+		dst := filepath.Join(dir, f.Name())
+		if err := os.Link(src, dst); err == nil {
+			sum, err := checksum(dst)
+			if err != nil {
+				return nil, err
+			}
+			exported = append(exported, ExportedFile{Name: f.Name(), Size: f.Size(), SHA256: sum})
+			continue
+		}
+		r, err := os.Open(src)
+		if err != nil {
+			return nil, fmt.Errorf("can't export backup: %w", err)
+		}
+		e, err := exportFile(dst, r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		exported = append(exported, e)
+	}
+	return exported, nil
+}
+
+// exportFile copies r to a new file called name, describing it for the
+// manifest.
+func exportFile(name string, r io.Reader) (ExportedFile, error) {
+	e := ExportedFile{Name: filepath.Base(name)}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return e, fmt.Errorf("can't export %s: %w", e.Name, err)
+	}
+	h := sha256.New()
+	e.Size, err = io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return e, fmt.Errorf("can't export %s: %w", e.Name, err)
+	}
+	e.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return e, nil
+}
+
+// checksum returns the hex encoded SHA-256 checksum of the file name.
+func checksum(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
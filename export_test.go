@@ -0,0 +1,59 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportTo(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestExportTo", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      filepath.Join(dir, "logs", "foobar.log"),
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := filepath.Base(backupFile(filepath.Join(dir, "logs")))
+	_, err = l.Write([]byte("foo!foo!"))
+	isNil(err, t)
+
+	out := filepath.Join(dir, "export")
+	isNil(l.ExportTo(out), t)
+	existsWithContent(filepath.Join(out, "foobar.log"), []byte("foo!foo!"), t)
+	existsWithContent(filepath.Join(out, backup), []byte("boo!"), t)
+	fileCount(out, 3, t)
+
+	b, err := ioutil.ReadFile(filepath.Join(out, exportManifest))
+	isNil(err, t)
+	var m ExportManifest
+	isNil(json.Unmarshal(b, &m), t)
+	equals(l.Filename, m.LogFile, t)
+	equals(2, len(m.Files), t)
+	sum, err := checksum(filepath.Join(out, "foobar.log"))
+	isNil(err, t)
+	equals(ExportedFile{Name: "foobar.log", Size: 8, SHA256: sum, Current: true}, m.Files[0], t)
+	sum, err = checksum(filepath.Join(out, backup))
+	isNil(err, t)
+	equals(ExportedFile{Name: backup, Size: 4, SHA256: sum}, m.Files[1], t)
+
+	// writing goes on to the log file, not the export.
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	existsWithContent(filepath.Join(out, "foobar.log"), []byte("foo!foo!"), t)
+
+	// an existing directory isn't written to.
+	err = l.ExportTo(out)
+	assert(os.IsExist(err), t, "expected an exist error, got %v", err)
+	fileCount(dir, 2, t)
+}
@@ -0,0 +1,71 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// failover switches writes to FallbackFilename after cause prevented writing
+// to Filename.  It returns nil if the fallback file is now open and ready for
+// a write of writeLen bytes, and cause otherwise.
+func (l *Logger) failover(cause error, writeLen int) error {
+	if l.FallbackFilename == "" || l.fallbackActive() {
+		return cause
+	}
+	_ = l.close()
+	atomic.StoreInt32(&l.usingFallback, 1)
+	l.lastProbe = currentTime()
+	if err := l.openExistingOrNew(writeLen); err != nil {
+		atomic.StoreInt32(&l.usingFallback, 0)
+		return cause
+	}
+	l.emit(Event{Type: EventFailover, Filename: l.filename(), Err: cause})
+	return nil
+}
+
+// failback switches writes back to Filename if the Logger has failed over and
+// Filename has become writable again.  Filename is only retried once every
+// failbackInterval, so a persistent failure doesn't cost a probe per write.
+func (l *Logger) failback() {
+	if !l.fallbackActive() {
+		return
+	}
+	now := currentTime()
+	if now.Sub(l.lastProbe) < failbackInterval {
+		return
+	}
+	l.lastProbe = now
+
+	name := l.primaryFilename()
+	if err := probeWritable(name); err != nil {
+		return
+	}
+	_ = l.close()
+	atomic.StoreInt32(&l.usingFallback, 0)
+	if err := l.openExistingOrNew(0); err != nil {
+		atomic.StoreInt32(&l.usingFallback, 1)
+		_ = l.openExistingOrNew(0)
+		return
+	}
+	l.emit(Event{Type: EventFailback, Filename: name})
+}
+
+// fallbackActive reports whether writes are currently going to
+// FallbackFilename.
+func (l *Logger) fallbackActive() bool {
+	return atomic.LoadInt32(&l.usingFallback) == 1
+}
+
+// probeWritable checks that name can be opened for appending, creating it and
+// its directory if necessary.
+func probeWritable(name string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
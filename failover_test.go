@@ -0,0 +1,124 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFailoverAndFailback(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFailoverAndFailback", t)
+	defer os.RemoveAll(dir)
+
+	// A regular file where the primary log directory should be makes the
+	// primary path unusable.
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+
+	primary := filepath.Join(blocker, "foobar.log")
+	fallback := filepath.Join(dir, "fallback", "foobar.log")
+
+	var events []Event
+	l := &Logger{
+		Filename:         primary,
+		FallbackFilename: fallback,
+		OnEvent:          func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(fallback, b, t)
+	equals(1, len(events), t)
+	equals(EventFailover, events[0].Type, t)
+	equals(fallback, events[0].Filename, t)
+	notNil(events[0].Err, t)
+
+	// fix the primary location, but don't let enough time pass for a retry.
+	err = os.Remove(blocker)
+	isNil(err, t)
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(fallback, append(b, b2...), t)
+
+	fakeCurrentTime = fakeCurrentTime.Add(failbackInterval)
+	b3 := []byte("baaaaar!")
+	_, err = l.Write(b3)
+	isNil(err, t)
+	existsWithContent(primary, b3, t)
+	equals(2, len(events), t)
+	equals(EventFailback, events[1].Type, t)
+	equals(primary, events[1].Filename, t)
+}
+
+func TestFailoverUnset(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFailoverUnset", t)
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+
+	l := &Logger{
+		Filename: filepath.Join(blocker, "foobar.log"),
+	}
+	defer l.Close()
+	n, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+	equals(0, n, t)
+}
+
+func TestFailoverDuringMill(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFailoverDuringMill", t)
+	defer os.RemoveAll(dir)
+
+	// the primary and fallback directories hold backups with the same names.
+	primary := filepath.Join(dir, "primary", "foobar.log")
+	fallback := filepath.Join(dir, "fallback", "foobar.log")
+	isNil(os.MkdirAll(filepath.Dir(primary), 0755), t)
+	isNil(os.MkdirAll(filepath.Dir(fallback), 0755), t)
+	var names []string
+	for i := 0; i < 4; i++ {
+		newFakeTime()
+		name := filepath.Base(backupFile(dir))
+		names = append(names, name)
+		isNil(ioutil.WriteFile(filepath.Join(filepath.Dir(primary), name), []byte("boo!"), 0644), t)
+		isNil(ioutil.WriteFile(filepath.Join(filepath.Dir(fallback), name), []byte("boo!"), 0644), t)
+	}
+
+	l := &Logger{
+		Filename:         primary,
+		FallbackFilename: fallback,
+		MaxBackups:       2,
+		Compress:         true,
+		ManualCleanup:    true,
+	}
+	// switch to the fallback while the mill is compressing.
+	l.Stat = func(name string) (os.FileInfo, error) {
+		atomic.StoreInt32(&l.usingFallback, 1)
+		return os.Stat(name)
+	}
+	defer l.Close()
+
+	isNil(l.CleanNow(), t)
+	for _, name := range names[:2] {
+		notExist(filepath.Join(filepath.Dir(primary), name), t)
+	}
+	for _, name := range names[2:] {
+		exists(filepath.Join(filepath.Dir(primary), name+compressSuffix), t)
+	}
+	// the fallback directory is left alone.
+	for _, name := range names {
+		existsWithContent(filepath.Join(filepath.Dir(fallback), name), []byte("boo!"), t)
+	}
+}
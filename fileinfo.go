@@ -0,0 +1,36 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// FileInfo returns the os.FileInfo of the log file that is open, taken from
+// the open file rather than its name, so that monitoring code gets the size
+// and mode of the file actually being written to without racing a rotation.
+// The size is what has reached the file, not counting writes still held back
+// by BatchWindow or CompressActive.  It returns an error if the log file
+// isn't open.
+func (l *Logger) FileInfo() (os.FileInfo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil, errors.New("log file is not open")
+	}
+	l.waitHandoff()
+	info, err := l.file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	// a file opened by rotateHandoff still has its temporary name, so look
+	// it up by its real one, which can't change while l.mu is held.
+	name := l.filename()
+	if info.Name() != filepath.Base(name) {
+		if named, err := os.Stat(name); err == nil && os.SameFile(info, named) {
+			return named, nil
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,43 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileInfo(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFileInfo", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:    logFile(dir),
+		MaxSize:     10,
+		AsyncRotate: true,
+	}
+	defer l.Close()
+
+	_, err := l.FileInfo()
+	notNil(err, t)
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	info, err := l.FileInfo()
+	isNil(err, t)
+	equals(filepath.Base(logFile(dir)), info.Name(), t)
+	equals(int64(4), info.Size(), t)
+	equals(os.FileMode(0600), info.Mode(), t)
+
+	newFakeTime()
+	_, err = l.Write([]byte("foooooo!"))
+	isNil(err, t)
+	info, err = l.FileInfo()
+	isNil(err, t)
+	equals(filepath.Base(logFile(dir)), info.Name(), t)
+	equals(int64(8), info.Size(), t)
+	onDisk, err := os.Stat(logFile(dir))
+	isNil(err, t)
+	assert(os.SameFile(info, onDisk), t, "expected the open file to be the log file")
+}
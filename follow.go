@@ -0,0 +1,233 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"sync"
+)
+
+// Follow returns a reader that streams the log as it is written, like
+// `tail -F`, starting from the beginning of the current log file.  When the
+// Logger rotates, the reader finishes reading the old file and then carries on
+// with the new one, so nothing is missed or read twice.  When it has caught up,
+// Read blocks until more is written or ctx is done, in which case it returns
+// ctx.Err().  With CompressActive, what is read is decompressed, and only
+// comes through once it has been flushed to the file.  Close the reader to
+// stop following.
+func (l *Logger) Follow(ctx context.Context) (io.ReadCloser, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f := &follower{
+		l:    l,
+		ctx:  ctx,
+		wake: make(chan struct{}, 1),
+	}
+	if err := f.add(l.filename()); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if l.followers == nil {
+		l.followers = make(map[*follower]struct{})
+	}
+	l.followers[f] = struct{}{}
+	return f, nil
+}
+
+// follower is the reader returned by Follow.
+type follower struct {
+	l    *Logger
+	ctx  context.Context
+	wake chan struct{}
+
+	mu     sync.Mutex
+	cur    *followed
+	queue  []*followed
+	last   os.FileInfo
+	closed bool
+}
+
+// followed is a file that a follower reads.  r reads it, decompressing it
+// if it is a gzip stream that CompressActive is writing, in which case it
+// waits for more to be written itself, and only reaches EOF at the end of
+// the file once the Logger has moved on from it.
+type followed struct {
+	file *os.File
+	r    io.Reader
+	gz   bool
+}
+
+// add opens the named file and queues it to be read after the files already
+// queued, unless it's the same file as the last one queued.
+func (f *follower) add(name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed || (f.last != nil && os.SameFile(f.last, info)) {
+		file.Close()
+		return nil
+	}
+	fd := &followed{file: file, r: file}
+	if f.l.CompressActive {
+		fd.r = &followGzip{src: &followSource{f: f, file: file}}
+		fd.gz = true
+	}
+	f.queue = append(f.queue, fd)
+	f.last = info
+	f.signal()
+	return nil
+}
+
+// signal wakes up a blocked Read, if there is one.
+func (f *follower) signal() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Read implements io.Reader.
+func (f *follower) Read(p []byte) (int, error) {
+	for {
+		f.mu.Lock()
+		if f.closed {
+			f.mu.Unlock()
+			return 0, os.ErrClosed
+		}
+		if f.cur == nil && len(f.queue) > 0 {
+			f.cur, f.queue = f.queue[0], f.queue[1:]
+		}
+		cur := f.cur
+		// If another file is queued, the Logger has moved on and the current
+		// file won't grow any further, so EOF really is the end of it.
+		done := len(f.queue) > 0
+		f.mu.Unlock()
+
+		if cur != nil {
+			n, err := cur.r.Read(p)
+			if n > 0 {
+				return n, nil
+			}
+			if err != nil && err != io.EOF {
+				return 0, err
+			}
+			if done || (cur.gz && err == io.EOF) {
+				f.mu.Lock()
+				if f.cur == cur {
+					cur.file.Close()
+					f.cur = nil
+				}
+				f.mu.Unlock()
+				continue
+			}
+		}
+
+		select {
+		case <-f.ctx.Done():
+			return 0, f.ctx.Err()
+		case <-f.wake:
+		}
+	}
+}
+
+// Close implements io.Closer, and stops following the log.
+func (f *follower) Close() error {
+	f.l.mu.Lock()
+	delete(f.l.followers, f)
+	f.l.mu.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	if f.cur != nil {
+		f.cur.file.Close()
+	}
+	for _, fd := range f.queue {
+		fd.file.Close()
+	}
+	f.cur, f.queue = nil, nil
+	f.signal()
+	return nil
+}
+
+// followSource reads a file that CompressActive is writing, for followGzip
+// to decompress, waiting for more to be written when it gets to the end,
+// unless the follower has moved on to another file.
+type followSource struct {
+	f    *follower
+	file *os.File
+}
+
+// Read implements io.Reader.
+func (s *followSource) Read(p []byte) (int, error) {
+	for {
+		s.f.mu.Lock()
+		closed := s.f.closed
+		// as in follower.Read, EOF is only the end of the file if another
+		// file was already queued before reading.
+		done := len(s.f.queue) > 0
+		s.f.mu.Unlock()
+		if closed {
+			return 0, os.ErrClosed
+		}
+
+		n, err := s.file.Read(p)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+		if done {
+			return 0, io.EOF
+		}
+		select {
+		case <-s.f.ctx.Done():
+			return 0, s.f.ctx.Err()
+		case <-s.f.wake:
+		}
+	}
+}
+
+// followGzip decompresses src, only starting on the gzip header once it is
+// first read, since reading src can block.
+type followGzip struct {
+	src io.Reader
+	gz  *gzip.Reader
+}
+
+// Read implements io.Reader.
+func (g *followGzip) Read(p []byte) (int, error) {
+	if g.gz == nil {
+		gz, err := gzip.NewReader(g.src)
+		if err != nil {
+			return 0, err
+		}
+		g.gz = gz
+	}
+	return g.gz.Read(p)
+}
+
+// followFile tells the followers that the Logger is now writing to name.
+func (l *Logger) followFile(name string) {
+	for f := range l.followers {
+		_ = f.add(name)
+	}
+}
+
+// wakeFollowers tells the followers that there is more to read.
+func (l *Logger) wakeFollowers() {
+	for f := range l.followers {
+		f.signal()
+	}
+}
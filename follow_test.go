@@ -0,0 +1,74 @@
+package lumberjack
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFollow(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFollow", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	r, err := l.Follow(ctx)
+	isNil(err, t)
+	defer r.Close()
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(r, buf)
+	isNil(err, t)
+	equals("one\n", string(buf), t)
+
+	// this write goes over MaxSize, so the second half lands in a new file.
+	go func() {
+		<-time.After(10 * time.Millisecond)
+		l.Write([]byte("two\n"))
+		newFakeTime()
+		l.Write([]byte("three\n"))
+	}()
+	buf = make([]byte, 10)
+	_, err = io.ReadFull(r, buf)
+	isNil(err, t)
+	equals("two\nthree\n", string(buf), t)
+	exists(backupFile(dir), t)
+
+	isNil(r.Close(), t)
+	_, err = r.Read(buf)
+	equals(os.ErrClosed, err, t)
+}
+
+func TestFollowCancel(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestFollowCancel", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, err := l.Follow(ctx)
+	isNil(err, t)
+	defer r.Close()
+
+	cancel()
+	_, err = r.Read(make([]byte, 10))
+	equals(context.Canceled, err, t)
+}
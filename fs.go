@@ -0,0 +1,266 @@
+package lumberjack
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FS returns a read-only view of the log file and its backups as a flat
+// directory, for use with fs.WalkDir, http.FS and the like.  Backups appear
+// under the names they were given when they were rotated, and compressed
+// backups are decompressed as they are read, so a backup's name and content
+// don't change when it is compressed.  What the directory holds is looked up
+// afresh for each call.
+func (l *Logger) FS() fs.FS {
+	return logFS{l}
+}
+
+// logFS is the fs.FS returned by FS.
+type logFS struct {
+	l *Logger
+}
+
+// paths returns the paths of the files in the view, by name.
+func (f logFS) paths() (map[string]string, error) {
+	f.l.mu.Lock()
+	defer f.l.mu.Unlock()
+	backups, err := f.l.backupPaths()
+	if err != nil {
+		return nil, err
+	}
+	name := f.l.filename()
+	paths := map[string]string{filepath.Base(name): name}
+	for _, p := range backups {
+		paths[filepath.Base(p)] = p
+	}
+	return paths, nil
+}
+
+// Open implements fs.FS.
+func (f logFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		entries, err := f.ReadDir(".")
+		if err != nil {
+			return nil, err
+		}
+		return &logDir{entries: entries}, nil
+	}
+	paths, err := f.paths()
+	if err != nil {
+		return nil, err
+	}
+	path, ok := paths[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	file, err := os.Open(path)
+	if !os.IsNotExist(err) {
+		return file, err
+	}
+	cpath := compressedVersion(path)
+	if cpath == "" {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	d := &decompressedFile{path: cpath, name: name}
+	if err := d.reopen(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f logFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." {
+		if !fs.ValidPath(name) {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	paths, err := f.paths()
+	if err != nil {
+		return nil, err
+	}
+	var entries []fs.DirEntry
+	for name, path := range paths {
+		info, err := os.Stat(path)
+		if err == nil {
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+			continue
+		}
+		if cpath := compressedVersion(path); cpath != "" {
+			if info, err := os.Stat(cpath); err == nil {
+				d := &decompressedFile{path: cpath, name: name}
+				entries = append(entries, fs.FileInfoToDirEntry(&decompressedInfo{info, d}))
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// logDir is the root directory of a logFS.
+type logDir struct {
+	entries []fs.DirEntry
+	off     int
+}
+
+func (d *logDir) Stat() (fs.FileInfo, error) { return dirInfo{}, nil }
+func (d *logDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: errors.New("is a directory")}
+}
+func (d *logDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *logDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.off:]
+	if n <= 0 {
+		d.off = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.off += n
+	return rest[:n], nil
+}
+
+// dirInfo describes the root directory of a logFS.
+type dirInfo struct{}
+
+func (dirInfo) Name() string       { return "." }
+func (dirInfo) Size() int64        { return 0 }
+func (dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (dirInfo) ModTime() time.Time { return time.Time{} }
+func (dirInfo) IsDir() bool        { return true }
+func (dirInfo) Sys() interface{}   { return nil }
+
+// decompressedFile is a compressed backup, opened in a logFS.  It can seek,
+// though seeking backwards means decompressing again from the start.
+type decompressedFile struct {
+	path string
+	name string
+	r    io.ReadCloser
+	pos  int64 // where reads will come from
+	rpos int64 // where r is
+	size int64 // the decompressed size, or 0 if not yet known
+}
+
+// reopen starts decompressing the file again from the start.
+func (d *decompressedFile) reopen() error {
+	if d.r != nil {
+		d.r.Close()
+	}
+	r, err := openBackup(d.path)
+	if err != nil {
+		d.r = nil
+		return err
+	}
+	d.r, d.rpos = r, 0
+	return nil
+}
+
+func (d *decompressedFile) Read(p []byte) (int, error) {
+	if d.r == nil {
+		return 0, os.ErrClosed
+	}
+	if d.pos < d.rpos {
+		if err := d.reopen(); err != nil {
+			return 0, err
+		}
+	}
+	if d.pos > d.rpos {
+		n, err := io.CopyN(io.Discard, d.r, d.pos-d.rpos)
+		d.rpos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := d.r.Read(p)
+	d.pos += int64(n)
+	d.rpos += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (d *decompressedFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += d.pos
+	case io.SeekEnd:
+		size, err := d.decompressedSize()
+		if err != nil {
+			return 0, err
+		}
+		offset += size
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: d.name, Err: fs.ErrInvalid}
+	}
+	d.pos = offset
+	return offset, nil
+}
+
+// decompressedSize returns the size of the file's decompressed content,
+// reading it all to find out the first time.
+func (d *decompressedFile) decompressedSize() (int64, error) {
+	if d.size > 0 {
+		return d.size, nil
+	}
+	r, err := openBackup(d.path)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	n, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return 0, err
+	}
+	d.size = n
+	return n, nil
+}
+
+func (d *decompressedFile) Stat() (fs.FileInfo, error) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return nil, err
+	}
+	return &decompressedInfo{info, d}, nil
+}
+
+func (d *decompressedFile) Close() error {
+	if d.r == nil {
+		return os.ErrClosed
+	}
+	err := d.r.Close()
+	d.r = nil
+	return err
+}
+
+// decompressedInfo describes a compressed backup by its decompressed name and
+// size.
+type decompressedInfo struct {
+	fs.FileInfo
+	d *decompressedFile
+}
+
+func (i *decompressedInfo) Name() string { return i.d.name }
+
+// Size returns the decompressed size, or -1 if it can't be found.
+func (i *decompressedInfo) Size() int64 {
+	size, err := i.d.decompressedSize()
+	if err != nil {
+		return -1
+	}
+	return size
+}
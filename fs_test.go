@@ -0,0 +1,75 @@
+package lumberjack
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestFS", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := filepath.Base(backupFile(dir))
+
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	second := filepath.Base(backupFile(dir))
+
+	_, err = l.Write([]byte("three\n"))
+	isNil(err, t)
+
+	// compressing the first backup doesn't change its name or content.
+	err = l.compressLogFile(filepath.Join(dir, first), filepath.Join(dir, first)+compressSuffix, codecs["gzip"], false)
+	isNil(err, t)
+
+	fsys := l.FS()
+	isNil(fstest.TestFS(fsys, filepath.Base(l.Filename), first, second), t)
+
+	b, err := fs.ReadFile(fsys, first)
+	isNil(err, t)
+	equals("one\n", string(b), t)
+	b, err = fs.ReadFile(fsys, second)
+	isNil(err, t)
+	equals("two\n", string(b), t)
+	b, err = fs.ReadFile(fsys, filepath.Base(l.Filename))
+	isNil(err, t)
+	equals("three\n", string(b), t)
+
+	info, err := fs.Stat(fsys, first)
+	isNil(err, t)
+	equals(first, info.Name(), t)
+	equals(int64(4), info.Size(), t)
+
+	f, err := fsys.Open(first)
+	isNil(err, t)
+	defer f.Close()
+	s := f.(io.ReadSeeker)
+	_, err = s.Seek(1, io.SeekStart)
+	isNil(err, t)
+	b, err = io.ReadAll(s)
+	isNil(err, t)
+	equals("ne\n", string(b), t)
+
+	_, err = fsys.Open(first + compressSuffix)
+	assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+}
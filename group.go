@@ -0,0 +1,64 @@
+package lumberjack
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Group is a set of related Loggers, such as one for each tenant or each
+// level, that can be rotated together so that their backups line up for
+// archiving and correlating.  A Logger may belong to more than one Group.
+type Group struct {
+	// Loggers are the members of the group.
+	Loggers []*Logger
+
+	mu sync.Mutex
+}
+
+// RotateAll rotates every Logger in the group at once, as Rotate does, naming
+// all of their backups with the same timestamp.  No Logger in the group is
+// written to until they have all been rotated, so that each backup ends with
+// the same moment as the others.  If any of them can't be rotated, the rest
+// still are, and the returned error joins together the errors.
+func (g *Group) RotateAll() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var members []*Logger
+	seen := make(map[*Logger]bool)
+	for _, l := range g.Loggers {
+		if l != nil && !seen[l] {
+			seen[l] = true
+			members = append(members, l)
+		}
+	}
+	// the locks are taken in order of address, so that two Groups sharing
+	// Loggers listed in different orders can't deadlock.
+	locking := append([]*Logger(nil), members...)
+	sort.Slice(locking, func(i, j int) bool {
+		return uintptr(unsafe.Pointer(locking[i])) < uintptr(unsafe.Pointer(locking[j]))
+	})
+	for _, l := range locking {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
+
+	t := currentTime()
+	var errs []error
+	for _, l := range members {
+		if err := l.checkClosed(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		l.rotateTime = t
+		err := l.rotate(RotateManual)
+		l.rotateTime = time.Time{}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGroupRotateAll(t *testing.T) {
+	// a clock that moves on every time it is read.
+	now := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestGroupRotateAll", t)
+	defer os.RemoveAll(dir)
+
+	a := &Logger{Filename: filepath.Join(dir, "a.log")}
+	defer a.Close()
+	b := &Logger{Filename: filepath.Join(dir, "b.log")}
+	defer b.Close()
+	g := &Group{Loggers: []*Logger{a, b, a, nil}}
+
+	_, err := a.Write([]byte("boo!"))
+	isNil(err, t)
+	_, err = b.Write([]byte("foo!"))
+	isNil(err, t)
+
+	isNil(g.RotateAll(), t)
+	var stamp string
+	files, err := os.ReadDir(dir)
+	isNil(err, t)
+	for _, f := range files {
+		if f.Name() == "a.log" || f.Name() == "b.log" {
+			continue
+		}
+		s := f.Name()[2 : len(f.Name())-len(".log")]
+		if stamp == "" {
+			stamp = s
+		}
+		equals(stamp, s, t)
+	}
+	assert(stamp != "", t, "expected backups")
+	existsWithContent(filepath.Join(dir, "a-"+stamp+".log"), []byte("boo!"), t)
+	existsWithContent(filepath.Join(dir, "b-"+stamp+".log"), []byte("foo!"), t)
+	fileCount(dir, 4, t)
+}
+
+func TestGroupsSharingLoggers(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestGroupsSharingLoggers", t)
+	defer os.RemoveAll(dir)
+
+	a := &Logger{Filename: filepath.Join(dir, "a.log"), ManualCleanup: true}
+	b := &Logger{Filename: filepath.Join(dir, "b.log"), ManualCleanup: true}
+	g1 := &Group{Loggers: []*Logger{a, b}}
+	g2 := &Group{Loggers: []*Logger{b, a}}
+
+	// two Groups listing the same Loggers in opposite orders don't deadlock.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for _, g := range []*Group{g1, g2} {
+			wg.Add(1)
+			go func(g *Group) {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					_ = g.RotateAll()
+				}
+			}(g)
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("RotateAll deadlocked")
+	}
+	isNil(a.Close(), t)
+	isNil(b.Close(), t)
+}
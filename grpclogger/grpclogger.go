@@ -0,0 +1,91 @@
+// Package grpclogger provides a logger for gRPC's internal logging that
+// writes to lumberjack Loggers, so that it gets rotation and retention too.
+// It implements grpclog.LoggerV2 without importing gRPC:
+//
+//	grpclog.SetLoggerV2(grpclogger.New(&lumberjack.Logger{
+//	    Filename: "/var/log/myapp/grpc.log",
+//	}, nil))
+//
+// Lines are formatted as gRPC's own default logger formats them, such as
+// "WARNING: 2006/01/02 15:04:05 message".
+package grpclogger
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+// exit exists so it can be mocked out by tests.
+var exit = os.Exit
+
+// Logger is a grpclog.LoggerV2 that writes info and warning logs to one
+// writer and error and fatal logs to another.
+type Logger struct {
+	// Verbosity is the highest verbosity level that V reports as enabled.
+	// It defaults to 0, as gRPC's own logger does.
+	Verbosity int
+
+	info, warning, err, fatal *log.Logger
+}
+
+// New returns a Logger that writes info and warning logs to out and error
+// and fatal logs to errs.  If errs is nil, everything is written to out.
+func New(out, errs io.Writer) *Logger {
+	if errs == nil {
+		errs = out
+	}
+	return &Logger{
+		info:    log.New(out, "INFO: ", log.LstdFlags),
+		warning: log.New(out, "WARNING: ", log.LstdFlags),
+		err:     log.New(errs, "ERROR: ", log.LstdFlags),
+		fatal:   log.New(errs, "FATAL: ", log.LstdFlags),
+	}
+}
+
+// Info logs args, formatted as by fmt.Print.
+func (l *Logger) Info(args ...interface{}) { l.info.Print(args...) }
+
+// Infoln logs args, formatted as by fmt.Println.
+func (l *Logger) Infoln(args ...interface{}) { l.info.Println(args...) }
+
+// Infof logs args, formatted as by fmt.Printf.
+func (l *Logger) Infof(format string, args ...interface{}) { l.info.Printf(format, args...) }
+
+// Warning logs args, formatted as by fmt.Print.
+func (l *Logger) Warning(args ...interface{}) { l.warning.Print(args...) }
+
+// Warningln logs args, formatted as by fmt.Println.
+func (l *Logger) Warningln(args ...interface{}) { l.warning.Println(args...) }
+
+// Warningf logs args, formatted as by fmt.Printf.
+func (l *Logger) Warningf(format string, args ...interface{}) { l.warning.Printf(format, args...) }
+
+// Error logs args, formatted as by fmt.Print.
+func (l *Logger) Error(args ...interface{}) { l.err.Print(args...) }
+
+// Errorln logs args, formatted as by fmt.Println.
+func (l *Logger) Errorln(args ...interface{}) { l.err.Println(args...) }
+
+// Errorf logs args, formatted as by fmt.Printf.
+func (l *Logger) Errorf(format string, args ...interface{}) { l.err.Printf(format, args...) }
+
+// Fatal logs args, formatted as by fmt.Print, and exits with status 1.
+func (l *Logger) Fatal(args ...interface{}) { l.die(fmt.Sprint(args...)) }
+
+// Fatalln logs args, formatted as by fmt.Println, and exits with status 1.
+func (l *Logger) Fatalln(args ...interface{}) { l.die(fmt.Sprintln(args...)) }
+
+// Fatalf logs args, formatted as by fmt.Printf, and exits with status 1.
+func (l *Logger) Fatalf(format string, args ...interface{}) { l.die(fmt.Sprintf(format, args...)) }
+
+func (l *Logger) die(s string) {
+	_ = l.fatal.Output(3, s)
+	exit(1)
+}
+
+// V reports whether logs at verbosity level lvl are enabled.
+func (l *Logger) V(lvl int) bool {
+	return lvl <= l.Verbosity
+}
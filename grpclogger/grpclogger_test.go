@@ -0,0 +1,104 @@
+package grpclogger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// loggerV2 is grpclog.LoggerV2, which Logger must implement.
+type loggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+var _ loggerV2 = (*Logger)(nil)
+
+const stamp = `\d{4}/\d\d/\d\d \d\d:\d\d:\d\d `
+
+func TestSeparateErrors(t *testing.T) {
+	var out, errs bytes.Buffer
+	l := New(&out, &errs)
+	l.Info("one ", 1)
+	l.Warningf("two %d", 2)
+	l.Errorln("three", 3)
+
+	matches(t, out.String(), "^INFO: "+stamp+"one 1\nWARNING: "+stamp+"two 2\n$")
+	matches(t, errs.String(), "^ERROR: "+stamp+"three 3\n$")
+}
+
+func TestFatal(t *testing.T) {
+	var code int
+	exit = func(c int) { code = c }
+	defer func() { exit = os.Exit }()
+
+	var out bytes.Buffer
+	l := New(&out, nil)
+	l.Fatalf("bye %s", "now")
+	if code != 1 {
+		t.Errorf("expected exit status 1, got %d", code)
+	}
+	matches(t, out.String(), "^FATAL: "+stamp+"bye now\n$")
+}
+
+func TestV(t *testing.T) {
+	l := New(ioutil.Discard, nil)
+	if !l.V(0) || l.V(1) {
+		t.Error("expected only level 0 to be enabled by default")
+	}
+	l.Verbosity = 2
+	if !l.V(2) || l.V(3) {
+		t.Error("expected levels up to 2 to be enabled")
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "TestWithLogger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := &lumberjack.Logger{Filename: filepath.Join(dir, "grpc.log")}
+	defer out.Close()
+	errs := &lumberjack.Logger{Filename: filepath.Join(dir, "grpc-errors.log")}
+	defer errs.Close()
+
+	l := New(out, errs)
+	l.Info("hello")
+	l.Error("oops")
+
+	b, err := ioutil.ReadFile(out.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches(t, string(b), "^INFO: "+stamp+"hello\n$")
+	b, err = ioutil.ReadFile(errs.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches(t, string(b), "^ERROR: "+stamp+"oops\n$")
+}
+
+func matches(t *testing.T, s, pattern string) {
+	t.Helper()
+	if !regexp.MustCompile(pattern).MatchString(s) {
+		t.Errorf("%q doesn't match %q", s, pattern)
+	}
+}
@@ -0,0 +1,262 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+)
+
+// nextName returns the name that the next log file is opened under, before
+// it is renamed into place.  It doesn't look like a backup, so cleanup leaves
+// it alone.
+func nextName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".next")
+}
+
+// canHandoff reports whether rotation can switch to the new log file before
+// the old one has been moved out of the way.  Windows won't rename a file
+// that is open.
+func (l *Logger) canHandoff() bool {
+	return l.AsyncRotate && !l.CompressActive && l.file != nil && runtime.GOOS != "windows"
+}
+
+// rotateHandoff rotates by opening the new log file under a temporary name
+// and switching writes to it, leaving the renames that put the old file aside
+// and the new one in its place to a goroutine, so that the Write that
+// triggered the rotation isn't held up by them.  It returns false, having
+// done nothing, if the temporary name is taken.
+func (l *Logger) rotateHandoff() (bool, error) {
+	name := l.filename()
+	l.current = name
+	next := nextName(name)
+	info, err := l.file.Stat()
+	if err != nil {
+		return false, nil
+	}
+	backup := l.backupName(name)
+	f := l.takeSpare(name)
+	if f == nil {
+		if _, err := os.Lstat(next); !os.IsNotExist(err) {
+			return false, nil
+		}
+		// this is a no-op anywhere but linux
+		if err := l.chown(next, info); err != nil {
+			return true, err
+		}
+		f, err = os.OpenFile(next, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND|l.noFollow(), info.Mode())
+		if err != nil {
+			return true, failed(ErrOpenFailed, fmt.Errorf("can't open new logfile: %w", err))
+		}
+	}
+
+	if err := l.beginRotation(name, backup, next); err != nil {
+		f.Close()
+		os.Remove(next)
+		return true, failed(ErrRotateFailed, fmt.Errorf("can't record rotation: %w", err))
+	}
+
+	old := l.file
+	l.file = f
+	l.size = 0
+	l.unused = true
+	l.opened()
+	l.saveSchedule()
+	done := make(chan struct{})
+	l.handoff = done
+	var spare chan *os.File
+	if l.SpareFile {
+		spare = make(chan *os.File, 1)
+		l.spareCh = spare
+	}
+	go l.finishHandoff(old, f, name, backup, info.Size(), l.rotateReason(), done, spare)
+
+	l.followFile(next)
+	return true, l.writeHeader()
+}
+
+// finishHandoff does the rest of rotateHandoff's work: it closes the old log
+// file, renames it to backup and renames the new log file, cur, into its
+// place at name.  If the old file can't be moved aside, the new one is left
+// under its temporary name, which cleanup doesn't touch, while it is written
+// to, and the renames are tried again at the next rotation or Close.  If
+// spare is set, a new spare file is sent on it once the renames are done.
+func (l *Logger) finishHandoff(old, cur *os.File, name, backup string, size int64, reason RotateReason, done chan struct{}, spare chan *os.File) {
+	defer close(done)
+	defer l.endRotation(name)
+	next := nextName(name)
+	if spare != nil {
+		defer func() { spare <- l.createSpare(name) }()
+	}
+
+	cerr := l.closeFile(old)
+	var err error
+	if l.NumericBackups {
+		err = l.shiftBackups(name)
+	}
+	if err == nil {
+		err = l.renameBackup(name, backup)
+	}
+	if err != nil {
+		l.stranded = &strandedHandoff{name: name, backup: backup, reason: reason}
+		l.emit(Event{Type: EventRotate, Filename: backup, Source: name, Err: failed(ErrRotateFailed, fmt.Errorf("can't rename log file: %w", err))})
+		return
+	}
+	l.queueUpload(backup)
+	l.linkBackup(backup)
+	atomic.AddInt64(&l.backupBytes, size)
+	l.emitRotate(Event{Type: EventRotate, Filename: backup, Source: name, Size: size, Reason: reason, Err: cerr})
+
+	if err := l.rename(next, name); err != nil {
+		l.emit(Event{Type: EventRotate, Filename: name, Source: next, Err: failed(ErrRotateFailed, fmt.Errorf("can't rename new log file: %w", err))})
+	} else if l.AppendOnly {
+		if err := setAppendOnly(cur, true); err != nil {
+			l.emit(Event{Type: EventRotate, Filename: name, Err: fmt.Errorf("can't make log file append-only: %w", err)})
+		}
+	}
+	if !l.compresses(size) {
+		if err := l.makeReadOnly(backup); err != nil {
+			l.emit(Event{Type: EventRotate, Filename: backup, Err: err})
+		}
+	}
+	l.mill()
+}
+
+// strandedHandoff is a rotation by rotateHandoff whose renames couldn't be
+// made: the old log file is still at name, rather than backup, and the log
+// file being written is at nextName(name).  backup is cleared once the old
+// file has been moved aside.
+type strandedHandoff struct {
+	name   string
+	backup string
+	reason RotateReason
+}
+
+// retryHandoff tries again to make the renames that finishHandoff couldn't,
+// moving the old log file to its backup name and the one being written into
+// its place.  If that still can't be done, it returns an error and the log
+// file stays where it is, to be tried again later.  It must be called with
+// l.mu held, once the handoff is over.
+func (l *Logger) retryHandoff() error {
+	r := l.stranded
+	if r == nil {
+		return nil
+	}
+	if r.backup != "" {
+		info, _ := os.Lstat(r.name)
+		if err := l.renameBackup(r.name, r.backup); err != nil {
+			return failed(ErrRotateFailed, fmt.Errorf("can't rename log file: %w", err))
+		}
+		var size int64
+		if info != nil {
+			size = info.Size()
+		}
+		l.queueUpload(r.backup)
+		l.linkBackup(r.backup)
+		atomic.AddInt64(&l.backupBytes, size)
+		l.emitRotate(Event{Type: EventRotate, Filename: r.backup, Source: r.name, Size: size, Reason: r.reason})
+		r.backup = ""
+	}
+	if err := l.rename(nextName(r.name), r.name); err != nil {
+		return failed(ErrRotateFailed, fmt.Errorf("can't rename new log file: %w", err))
+	}
+	l.stranded = nil
+	l.followFile(r.name)
+	return nil
+}
+
+// handingOff reports whether the renames of a rotateHandoff are still under
+// way, during which the log file isn't yet where it belongs.
+func (l *Logger) handingOff() bool {
+	if l.handoff == nil {
+		return false
+	}
+	select {
+	case <-l.handoff:
+		l.handoff = nil
+		return false
+	default:
+		return true
+	}
+}
+
+// waitHandoff waits for the renames of a rotateHandoff to finish.
+func (l *Logger) waitHandoff() {
+	if l.handoff != nil {
+		<-l.handoff
+		l.handoff = nil
+	}
+}
+
+// prepareSpare starts creating a spare file to rotate to next, if SpareFile
+// is set and there isn't one already.
+func (l *Logger) prepareSpare() {
+	if !l.SpareFile || l.spareCh != nil {
+		return
+	}
+	name := l.filename()
+	ch := make(chan *os.File, 1)
+	l.spareCh = ch
+	go func() { ch <- l.createSpare(name) }()
+}
+
+// createSpare creates an empty spare file to rotate the log file name to, or
+// returns nil if it can't.  A file left in the way is only reused if it is
+// empty, since otherwise it may have log data in it from an interrupted
+// rotation.
+func (l *Logger) createSpare(name string) *os.File {
+	next := nextName(name)
+	mode := os.FileMode(0600)
+	info, err := l.stat(name)
+	if err == nil {
+		mode = info.Mode()
+	}
+	f, err := os.OpenFile(next, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND|l.noFollow(), mode)
+	if os.IsExist(err) {
+		if leftover, err := os.Lstat(next); err != nil || !leftover.Mode().IsRegular() || leftover.Size() != 0 {
+			return nil
+		}
+		f, err = os.OpenFile(next, os.O_WRONLY|os.O_APPEND|l.noFollow(), mode)
+	}
+	if err != nil {
+		return nil
+	}
+	if info != nil {
+		// this is a no-op anywhere but linux
+		if err := l.chown(next, info); err != nil {
+			f.Close()
+			return nil
+		}
+	}
+	return f
+}
+
+// takeSpare returns the spare file for rotating the log file name to, waiting
+// for it if it's still being created, or nil if there isn't one.  A spare made
+// for some other name, such as before a failover, is removed.
+func (l *Logger) takeSpare(name string) *os.File {
+	if l.spareCh == nil {
+		return nil
+	}
+	f := <-l.spareCh
+	l.spareCh = nil
+	if f != nil && f.Name() != nextName(name) {
+		f.Close()
+		os.Remove(f.Name())
+		return nil
+	}
+	return f
+}
+
+// dropSpare removes the spare file, if there is one.
+func (l *Logger) dropSpare() {
+	if l.spareCh == nil {
+		return
+	}
+	if f := <-l.spareCh; f != nil {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	l.spareCh = nil
+}
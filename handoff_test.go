@@ -0,0 +1,212 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAsyncRotate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestAsyncRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var events []Event
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     10,
+		AsyncRotate: true,
+		OnEvent:     func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	b3 := []byte("ba")
+	_, err = l.Write(b3)
+	isNil(err, t)
+
+	// once the renames are done, everything is where it belongs.
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(filename, append(b2, b3...), t)
+	notExist(nextName(filename), t)
+	fileCount(dir, 2, t)
+	equals(1, len(events), t)
+	equals(EventRotate, events[0].Type, t)
+	isNil(events[0].Err, t)
+}
+
+func TestAsyncRotateNameTaken(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestAsyncRotateNameTaken", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(nextName(filename), []byte("mine"), 0644), t)
+	l := &Logger{
+		Filename:    filename,
+		MaxSize:     10,
+		AsyncRotate: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// rotation happens the usual way, leaving the file in the way alone.
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(filename, []byte{}, t)
+	existsWithContent(nextName(filename), []byte("mine"), t)
+}
+
+func TestSpareFile(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestSpareFile", t)
+	defer os.RemoveAll(dir)
+
+	for _, async := range []bool{false, true} {
+		filename := logFile(dir)
+		l := &Logger{
+			Filename:    filename,
+			MaxSize:     10,
+			SpareFile:   true,
+			AsyncRotate: async,
+		}
+
+		b := []byte("boo!")
+		_, err := l.Write(b)
+		isNil(err, t)
+		<-time.After(10 * time.Millisecond)
+		existsWithContent(nextName(filename), []byte{}, t)
+
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		_, err = l.Write(b)
+		isNil(err, t)
+
+		// the spare became the log file, and a new spare is made.
+		<-time.After(10 * time.Millisecond)
+		existsWithContent(backupFile(dir), b, t)
+		existsWithContent(filename, b, t)
+		existsWithContent(nextName(filename), []byte{}, t)
+
+		// Close removes the spare.
+		isNil(l.Close(), t)
+		notExist(nextName(filename), t)
+		isNil(os.Remove(filename), t)
+	}
+}
+
+func TestSpareFileLeftover(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestSpareFileLeftover", t)
+	defer os.RemoveAll(dir)
+
+	// a spare with data in it is left alone.
+	filename := logFile(dir)
+	isNil(ioutil.WriteFile(nextName(filename), []byte("mine"), 0644), t)
+	l := &Logger{
+		Filename:  filename,
+		MaxSize:   10,
+		SpareFile: true,
+	}
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(filename, []byte{}, t)
+	existsWithContent(nextName(filename), []byte("mine"), t)
+}
+
+func TestAsyncRotateRenameFails(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestAsyncRotateRenameFails", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var mu sync.Mutex
+	fail := true
+	var events []Event
+	l := &Logger{
+		Filename:      filename,
+		MaxSize:       100,
+		MaxBackups:    1,
+		Compress:      true,
+		AsyncRotate:   true,
+		ManualCleanup: true,
+		Rename: func(oldpath, newpath string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				fail = false
+				return errors.New("no renaming today")
+			}
+			return os.Rename(oldpath, newpath)
+		},
+		OnEvent: func(e Event) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	first := backupFile(dir)
+	isNil(l.Rotate(), t)
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+
+	// the log file being written stays under its temporary name, where
+	// cleanup doesn't touch it.
+	l.mu.Lock()
+	l.waitHandoff()
+	l.mu.Unlock()
+	isNil(l.CleanNow(), t)
+	existsWithContent(filename, b, t)
+	existsWithContent(nextName(filename), b2, t)
+	notExist(first, t)
+	mu.Lock()
+	notNil(events[0].Err, t)
+	mu.Unlock()
+
+	// the next rotation makes the renames that were missed, then its own.
+	newFakeTime()
+	second := backupFile(dir)
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	existsWithContent(first, b, t)
+	existsWithContent(second, b2, t)
+	existsWithContent(filename, []byte{}, t)
+	notExist(nextName(filename), t)
+}
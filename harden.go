@@ -0,0 +1,123 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrUnsafePath is wrapped by the errors returned when Harden is set and the
+// log file, its directory or a backup's name isn't safe to write to.
+var ErrUnsafePath = errors.New("log path is not safe to write")
+
+// noFollow returns the flag to open files with so that a symlink isn't
+// followed, if Harden is set.
+func (l *Logger) noFollow() int {
+	if !l.Harden {
+		return 0
+	}
+	return oNoFollow
+}
+
+// checkPath returns an error wrapping ErrUnsafePath if Harden is set and the
+// log file name, or its directory, is a symlink, is writable by everyone or
+// is owned by someone other than this process's user or root, or if any
+// directory above that could be swapped out, as checkAncestors describes.
+// Nothing is checked on platforms without Unix permissions.
+func (l *Logger) checkPath(name string) error {
+	if !l.Harden || !hasOwners {
+		return nil
+	}
+	dir := filepath.Dir(name)
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, dir)
+	}
+	if err := checkOwner(dir, info); err != nil {
+		return err
+	}
+	if err := checkMode(dir, info); err != nil {
+		return err
+	}
+	if err := checkAncestors(dir); err != nil {
+		return err
+	}
+	info, err = os.Lstat(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, name)
+	}
+	if err := checkOwner(name, info); err != nil {
+		return err
+	}
+	return checkMode(name, info)
+}
+
+// checkAncestors returns an error wrapping ErrUnsafePath if any directory
+// above dir, up to the root, is owned by someone other than this process's
+// user or root, or is writable by everyone without the sticky bit, which
+// stops anyone renaming what others have put there, as in /tmp.  A symlink
+// along the way is checked by its owner, and the directories it leads to are
+// checked as well.
+func checkAncestors(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	paths := []string{abs}
+	if real, err := filepath.EvalSymlinks(abs); err == nil && real != abs {
+		paths = append(paths, real)
+	}
+	for _, path := range paths {
+		for d := filepath.Dir(path); ; d = filepath.Dir(d) {
+			info, err := os.Lstat(d)
+			if err != nil {
+				return err
+			}
+			if err := checkOwner(d, info); err != nil {
+				return err
+			}
+			if info.Mode()&(os.ModeSymlink|os.ModeSticky) == 0 {
+				if err := checkMode(d, info); err != nil {
+					return err
+				}
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// checkBackup returns an error wrapping ErrUnsafePath if Harden is set and
+// name, which a backup is about to be written to, is a symlink.
+func (l *Logger) checkBackup(name string) error {
+	if !l.Harden {
+		return nil
+	}
+	info, err := os.Lstat(name)
+	if err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("%w: %s is a symlink", ErrUnsafePath, name)
+	}
+	return nil
+}
+
+// checkMode returns an error wrapping ErrUnsafePath if name, described by
+// info, is writable by everyone.  A sticky directory is no better, since
+// anyone could still plant a symlink under the name of a backup yet to come.
+func checkMode(name string, info os.FileInfo) error {
+	if info.Mode().Perm()&0002 != 0 {
+		return fmt.Errorf("%w: %s is writable by everyone", ErrUnsafePath, name)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package lumberjack
+
+import (
+	"os"
+)
+
+// oNoFollow is nothing, since there's no way to refuse symlinks when
+// opening files on this platform.
+const oNoFollow = 0
+
+// hasOwners is whether files here have owners and Unix permissions.
+const hasOwners = false
+
+// checkOwner does nothing, since files don't have Unix owners on this
+// platform.
+func checkOwner(_ string, _ os.FileInfo) error {
+	return nil
+}
@@ -0,0 +1,28 @@
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// oNoFollow makes opening a symlink fail.
+const oNoFollow = syscall.O_NOFOLLOW
+
+// hasOwners is whether files here have owners and Unix permissions.
+const hasOwners = true
+
+// checkOwner returns an error wrapping ErrUnsafePath if name, described by
+// info, is owned by someone other than this process's user or root.
+func checkOwner(name string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	if uid := int(stat.Uid); uid != 0 && uid != os.Geteuid() {
+		return fmt.Errorf("%w: %s is owned by uid %d", ErrUnsafePath, name, uid)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writeHeader writes the Banner and calls the Header callback, if any, on a
+// freshly created log file, counting what they write toward the file's
+// size.
+func (l *Logger) writeHeader() error {
+	if l.Banner != "" {
+		if _, err := l.writeFile([]byte(l.banner())); err != nil {
+			return fmt.Errorf("can't write log file banner: %w", err)
+		}
+	}
+	if l.Header == nil {
+		return nil
+	}
+	if err := l.Header(writerFunc(l.writeFile)); err != nil {
+		return fmt.Errorf("can't write log file header: %w", err)
+	}
+	return nil
+}
+
+// banner returns the Banner with its placeholders filled in, ending in a
+// newline.
+func (l *Logger) banner() string {
+	return l.fillLine(l.Banner)
+}
+
+// fillLine returns s with the placeholders described for Banner filled in,
+// ending in a newline.
+func (l *Logger) fillLine(s string) string {
+	host, _ := os.Hostname()
+	t := currentTime()
+	if !l.LocalTime {
+		t = t.UTC()
+	}
+	r := strings.NewReplacer("{hostname}", host, "{pid}", strconv.Itoa(os.Getpid()))
+	b := formatTimed(r.Replace(s), t)
+	if !strings.HasSuffix(b, "\n") {
+		b += "\n"
+	}
+	return b
+}
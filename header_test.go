@@ -0,0 +1,91 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestHeader", t)
+	defer os.RemoveAll(dir)
+
+	header := []byte("# hdr\n")
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  20,
+		Header: func(w io.Writer) error {
+			_, err := w.Write(header)
+			return err
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(logFile(dir), append(header, b...), t)
+	equals(int64(len(header)+len(b)), l.size, t)
+
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+	existsWithContent(backupFile(dir), append(header, b...), t)
+	existsWithContent(logFile(dir), header, t)
+}
+
+func TestHeaderError(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestHeaderError", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		Header: func(w io.Writer) error {
+			return errors.New("boom")
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	notNil(err, t)
+}
+
+func TestBanner(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestBanner", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+		Banner:   "# {2006-01-02} {hostname} {pid}",
+		Header: func(w io.Writer) error {
+			_, err := w.Write([]byte("# hdr\n"))
+			return err
+		},
+	}
+	defer l.Close()
+
+	host, err := os.Hostname()
+	isNil(err, t)
+	banner := fmt.Sprintf("# %s %s %d\n", fakeTime().UTC().Format("2006-01-02"), host, os.Getpid())
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(logFile(dir), []byte(banner+"# hdr\nboo!"), t)
+	equals(int64(len(banner)+len("# hdr\n")+len(b)), l.size, t)
+
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+	banner = fmt.Sprintf("# %s %s %d\n", fakeTime().UTC().Format("2006-01-02"), host, os.Getpid())
+	existsWithContent(logFile(dir), []byte(banner+"# hdr\n"), t)
+}
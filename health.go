@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// Health reports whether the Logger is in a good state to keep writing logs,
+// for use in readiness probes and the like.  It returns an error if the
+// Logger has been closed, if the log file has been removed or replaced out
+// from under the Logger, if the most recent cleanup of old log files failed,
+// or if the disk holding the log file doesn't have room for it to grow to
+// MaxSize.  A log file that hasn't been opened yet, such as before the first
+// Write, is healthy as long as a file can be created in its directory.
+// Otherwise it returns nil.
+func (l *Logger) Health() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed && !l.ReopenOnWrite {
+		return ErrClosed
+	}
+	l.waitHandoff()
+	if l.file == nil {
+		// not opened yet, or to be reopened after an error; that is fine as
+		// long as it can be.
+		if err := probeDir(l.dir()); err != nil {
+			return err
+		}
+	} else if err := l.checkOpenFile(); err != nil {
+		return err
+	}
+
+	if millErr := l.LastError(); millErr != nil {
+		return fmt.Errorf("cleaning up old log files failed: %w", millErr)
+	}
+
+	// not knowing the free space isn't itself unhealthy.
+	if free, _, err := diskSpace(l.dir()); err == nil {
+		if need := l.max() - l.size; need > 0 && free < uint64(need) {
+			return fmt.Errorf("only %d bytes free for log file, need %d", free, need)
+		}
+	}
+	return nil
+}
+
+// checkOpenFile returns an error if the open log file is no longer the one at
+// its name.
+func (l *Logger) checkOpenFile() error {
+	name := l.filename()
+	open, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("can't stat open log file: %w", err)
+	}
+	onDisk, err := l.stat(name)
+	if err != nil {
+		return fmt.Errorf("can't stat log file: %w", err)
+	}
+	if !os.SameFile(open, onDisk) {
+		return fmt.Errorf("log file %s has been replaced", name)
+	}
+	return nil
+}
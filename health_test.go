@@ -0,0 +1,59 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHealth(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestHealth", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	// a Logger that hasn't written anything yet is ready to.
+	isNil(l.Health(), t)
+	notExist(logFile(dir), t)
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Health(), t)
+
+	l.setMillErr(errors.New("boom"))
+	notNil(l.Health(), t)
+	l.setMillErr(nil)
+	isNil(l.Health(), t)
+
+	err = os.Remove(logFile(dir))
+	isNil(err, t)
+	notNil(l.Health(), t)
+
+	// Close reports the error that nothing has returned yet.
+	err = l.Close()
+	assert(err != nil && strings.Contains(err.Error(), "boom"), t, "expected boom from Close, got %v", err)
+	assert(errors.Is(l.Health(), ErrClosed), t, "expected ErrClosed")
+}
+
+func TestHealthNotWritable(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestHealthNotWritable", t)
+	defer os.RemoveAll(dir)
+
+	// a file where the log directory should be.
+	blocker := filepath.Join(dir, "logs")
+	isNil(ioutil.WriteFile(blocker, nil, 0644), t)
+	l := &Logger{
+		Filename: filepath.Join(blocker, "foobar.log"),
+	}
+	defer l.Close()
+	err := l.Health()
+	assert(errors.Is(err, ErrDirNotWritable), t, "expected ErrDirNotWritable, got %v", err)
+}
@@ -0,0 +1,68 @@
+package lumberjack
+
+import (
+	"time"
+)
+
+// defaultHeartbeatLine is the line written for Heartbeat if HeartbeatLine
+// isn't set.
+const defaultHeartbeatLine = "heartbeat {2006-01-02T15:04:05Z07:00}"
+
+// startHeartbeat starts writing heartbeats, if Heartbeat is set and they
+// aren't being written already.
+func (l *Logger) startHeartbeat() {
+	if l.Heartbeat <= 0 || l.heartbeatStop != nil {
+		return
+	}
+	l.heartbeatStop = make(chan struct{})
+	go l.heartbeatRun(l.heartbeatStop)
+}
+
+// stopHeartbeat stops writing heartbeats, if they are being written, without
+// waiting for one that's under way, which may be waiting for the lock that
+// must be held to call this.
+func (l *Logger) stopHeartbeat() {
+	if l.heartbeatStop != nil {
+		close(l.heartbeatStop)
+		l.heartbeatStop = nil
+	}
+}
+
+// heartbeatRun writes heartbeats as they fall due until stop is closed.
+func (l *Logger) heartbeatRun(stop chan struct{}) {
+	t := time.NewTimer(l.Heartbeat)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			t.Reset(l.beat(stop))
+		}
+	}
+}
+
+// beat writes a heartbeat if nothing has been written to the log file for
+// Heartbeat, and returns how long to wait before checking again.
+func (l *Logger) beat(stop chan struct{}) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-stop:
+		return l.Heartbeat
+	default:
+	}
+	if idle := currentTime().Sub(l.lastWrite); idle >= 0 && idle < l.Heartbeat {
+		return l.Heartbeat - idle
+	}
+	line := l.HeartbeatLine
+	if line == "" {
+		line = defaultHeartbeatLine
+	}
+	// a heartbeat isn't one of the caller's writes, so it isn't counted for
+	// MaxWrites or passed on to Metrics.
+	l.beating = true
+	defer func() { l.beating = false }()
+	l.write([]byte(l.fillLine(line)))
+	return l.Heartbeat
+}
@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeartbeat", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Heartbeat:     time.Hour,
+		HeartbeatLine: "alive at {15:04}",
+	}
+	defer l.Close()
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// nothing is written while the log file is in use.
+	stop := make(chan struct{})
+	equals(time.Hour, l.beat(stop), t)
+	existsWithContent(logFile(dir), b, t)
+
+	newFakeTime()
+	equals(time.Hour, l.beat(stop), t)
+	line := "alive at " + fakeTime().UTC().Format("15:04") + "\n"
+	existsWithContent(logFile(dir), append(b, line...), t)
+
+	// and the heartbeat counts as a write.
+	equals(time.Hour, l.beat(stop), t)
+	existsWithContent(logFile(dir), append(b, line...), t)
+}
+
+func TestHeartbeatRun(t *testing.T) {
+	currentTime = time.Now
+	defer func() { currentTime = fakeTime }()
+	dir := makeTempDir("TestHeartbeatRun", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:  logFile(dir),
+		Heartbeat: 10 * time.Millisecond,
+	}
+	_, err := l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	<-time.After(100 * time.Millisecond)
+	isNil(l.Close(), t)
+
+	b, err := ioutil.ReadFile(logFile(dir))
+	isNil(err, t)
+	assert(bytes.Count(b, []byte("heartbeat ")) > 1, t, "expected heartbeats, got %q", b)
+
+	// Close stops them.
+	<-time.After(50 * time.Millisecond)
+	existsWithContent(logFile(dir), b, t)
+}
+
+func TestHeartbeatNotCounted(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHeartbeatNotCounted", t)
+	defer os.RemoveAll(dir)
+
+	m := &fakeMetrics{}
+	l := &Logger{
+		Filename:      logFile(dir),
+		Heartbeat:     time.Hour,
+		HeartbeatLine: "alive",
+		MaxWrites:     2,
+		Metrics:       m,
+	}
+	defer l.Close()
+	b := []byte("boo!\n")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// heartbeats don't count toward MaxWrites, or as bytes written.
+	stop := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		l.beat(stop)
+	}
+	equals(len(b), m.bytes, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	fileCount(dir, 1, t)
+	existsWithContent(logFile(dir), []byte("boo!\nalive\nalive\nalive\nboo!\n"), t)
+}
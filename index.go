@@ -0,0 +1,168 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexSuffix is appended to the log file name to get the name of the backup
+// index written when WriteIndex is set.
+const indexSuffix = ".index.json"
+
+// BackupIndex is the content of the sidecar index file that a Logger with
+// WriteIndex set keeps next to its log file, and that PinBackup records pins
+// in.
+type BackupIndex struct {
+	// Backups lists the backups, newest first.
+	Backups []BackupRecord `json:"backups"`
+
+	// Pinned lists the names, as they were rotated to, without any
+	// compression suffix, of the backups pinned by PinBackup.
+	Pinned []string `json:"pinned,omitempty"`
+}
+
+// BackupRecord describes one backup in a BackupIndex.
+type BackupRecord struct {
+	// Name is the backup's file name, relative to the log file's directory.
+	Name string `json:"name"`
+
+	// RotatedAt is the rotation time encoded in the backup's name.
+	RotatedAt time.Time `json:"rotated_at"`
+
+	// Size is the size of the log data in bytes, before any compression.
+	Size int64 `json:"size"`
+
+	// CompressedSize is the size of the backup file in bytes if it is
+	// compressed, and zero otherwise.
+	CompressedSize int64 `json:"compressed_size,omitempty"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the backup file as it is
+	// stored on disk.
+	SHA256 string `json:"sha256"`
+}
+
+// ReadIndex reads the backup index from the given path.
+func ReadIndex(path string) (*BackupIndex, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	idx := &BackupIndex{}
+	if err := json.Unmarshal(b, idx); err != nil {
+		return nil, fmt.Errorf("can't parse backup index: %w", err)
+	}
+	return idx, nil
+}
+
+// indexName returns the path of the backup index file.
+func (l *Logger) indexName() string {
+	return l.filename() + indexSuffix
+}
+
+// updateIndex rewrites the backup index to match the backups currently on
+// disk.  Records from the previous index are reused for files that haven't
+// changed, so each backup is only checksummed once.
+func (l *Logger) updateIndex() error {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return err
+	}
+	name := l.indexName()
+	known := map[string]BackupRecord{}
+	pinned := map[string]bool{}
+	if old, err := ReadIndex(name); err == nil {
+		for _, r := range old.Backups {
+			known[r.Name] = r
+		}
+		for _, p := range old.Pinned {
+			pinned[p] = true
+		}
+	}
+
+	idx := BackupIndex{Backups: []BackupRecord{}}
+	for _, f := range files {
+		// pins on backups that are gone are dropped.
+		if base := trimCompressExt(f.Name()); pinned[base] {
+			idx.Pinned = append(idx.Pinned, base)
+			delete(pinned, base)
+		}
+		r, ok := known[f.Name()]
+		if !ok || r.storedSize() != f.Size() {
+			if r, err = l.indexRecord(f); err != nil {
+				return err
+			}
+		}
+		idx.Backups = append(idx.Backups, r)
+	}
+
+	b, err := json.MarshalIndent(idx, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(name, b, 0644)
+}
+
+// storedSize is the size of the backup file the record describes.
+func (r BackupRecord) storedSize() int64 {
+	if r.CompressedSize != 0 {
+		return r.CompressedSize
+	}
+	return r.Size
+}
+
+// indexRecord builds the index record for a backup by reading it.
+func (l *Logger) indexRecord(f logInfo) (BackupRecord, error) {
+	r := BackupRecord{Name: f.Name(), RotatedAt: f.timestamp}
+	file, err := os.Open(filepath.Join(l.dir(), f.Name()))
+	if err != nil {
+		return r, fmt.Errorf("can't open backup for index: %w", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return r, fmt.Errorf("can't read backup for index: %w", err)
+	}
+	r.SHA256 = hex.EncodeToString(h.Sum(nil))
+	c := codecFor(f.Name())
+	if c == nil {
+		r.Size = n
+		return r, nil
+	}
+
+	r.CompressedSize = n
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return r, fmt.Errorf("can't read compressed backup for index: %w", err)
+	}
+	dr, err := c.decompress(file)
+	if err != nil {
+		return r, fmt.Errorf("can't read compressed backup for index: %w", err)
+	}
+	defer dr.Close()
+	if r.Size, err = io.Copy(ioutil.Discard, dr); err != nil {
+		return r, fmt.Errorf("can't read compressed backup for index: %w", err)
+	}
+	return r, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to name and renames it
+// into place, so readers never see a partially written file.
+func writeFileAtomic(name string, data []byte, mode os.FileMode) error {
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package lumberjack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteIndex(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestWriteIndex", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    10,
+		WriteIndex: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+
+	// the index is written on a different goroutine.
+	<-time.After(10 * time.Millisecond)
+
+	idx, err := ReadIndex(filename + indexSuffix)
+	isNil(err, t)
+	equals(1, len(idx.Backups), t)
+	r := idx.Backups[0]
+	equals(filepath.Base(backupFile(dir)), r.Name, t)
+	equals(int64(len(b)), r.Size, t)
+	equals(int64(0), r.CompressedSize, t)
+	sum := sha256.Sum256(b)
+	equals(hex.EncodeToString(sum[:]), r.SHA256, t)
+	assert(r.RotatedAt.Equal(fakeTime().UTC().Truncate(time.Millisecond)), t,
+		"wrong rotation time %v", r.RotatedAt)
+
+	// now compress and check the record is updated.
+	l.Compress = true
+	_, err = l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+
+	<-time.After(300 * time.Millisecond)
+
+	idx, err = ReadIndex(filename + indexSuffix)
+	isNil(err, t)
+	equals(2, len(idx.Backups), t)
+	for _, r := range idx.Backups {
+		equals(int64(len(b)), r.Size, t)
+		gz, err := ioutil.ReadFile(filepath.Join(dir, r.Name))
+		isNil(err, t)
+		equals(int64(len(gz)), r.CompressedSize, t)
+		sum := sha256.Sum256(gz)
+		equals(hex.EncodeToString(sum[:]), r.SHA256, t)
+	}
+	equals(filepath.Base(backupFile(dir))+compressSuffix, idx.Backups[0].Name, t)
+
+	// the index itself, the log file and two backups.
+	fileCount(dir, 4, t)
+}
@@ -0,0 +1,116 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// rotationIntent is what is recorded, with CrashSafeRotate, about a rotation
+// that is under way.
+type rotationIntent struct {
+	// Source is the log file being rotated, and Backup the name it is being
+	// moved to.
+	Source string `json:"source"`
+	Backup string `json:"backup"`
+
+	// Next is the new log file opened under a temporary name by
+	// rotateHandoff, if that is how the rotation is being done.
+	Next string `json:"next,omitempty"`
+}
+
+// intentName returns the name of the file that records a rotation of the log
+// file name that is under way.  It doesn't look like a backup, so cleanup
+// leaves it alone.
+func intentName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".rotating")
+}
+
+// beginRotation records, if CrashSafeRotate is set, that the log file is
+// about to be moved to backup, with the new log file opened as next if that
+// isn't empty, making sure the record is on disk first.
+func (l *Logger) beginRotation(name, backup, next string) error {
+	if !l.CrashSafeRotate {
+		return nil
+	}
+	b, err := json.Marshal(rotationIntent{Source: name, Backup: backup, Next: next})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(intentName(name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|l.noFollow(), 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(name))
+	return nil
+}
+
+// endRotation removes the record made by beginRotation.
+func (l *Logger) endRotation(name string) {
+	if l.CrashSafeRotate {
+		os.Remove(intentName(name))
+	}
+}
+
+// recoverRotation finishes a rotation of the log file name that was cut
+// short by a crash, if CrashSafeRotate is set and one was recorded.  A
+// rotation that hadn't moved the log file aside yet needs nothing done; one
+// that had is finished by putting the new log file in place, or, if the log
+// file had only been linked to its backup, by removing the log file's name.
+func (l *Logger) recoverRotation(name string) {
+	if !l.CrashSafeRotate {
+		return
+	}
+	b, err := os.ReadFile(intentName(name))
+	if err != nil {
+		return
+	}
+	defer l.endRotation(name)
+	var in rotationIntent
+	if json.Unmarshal(b, &in) != nil || in.Source != name {
+		return
+	}
+
+	src, srcErr := os.Lstat(name)
+	backup, backupErr := os.Lstat(in.Backup)
+	switch {
+	case srcErr == nil && backupErr == nil && os.SameFile(src, backup):
+		// AtomicCreate linked the log file to its backup, but didn't get
+		// to put the new one in its place.
+		os.Remove(name)
+		os.Remove(filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".new"))
+		srcErr = os.ErrNotExist
+	case srcErr == nil && backupErr != nil && in.Next != "":
+		// rotateHandoff had switched to the new file, but the old one
+		// hadn't been moved aside.
+		if l.renameBackup(name, in.Backup) != nil {
+			return
+		}
+		srcErr = os.ErrNotExist
+	}
+	if srcErr != nil && in.Next != "" {
+		if _, err := os.Lstat(in.Next); err == nil {
+			l.rename(in.Next, name)
+		}
+	}
+}
+
+// syncDir flushes the directory dir to disk, so that changes to what is in
+// it survive a crash.  It does nothing where directories can't be synced.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,95 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// writeIntent leaves behind the record of a rotation that was cut short.
+func writeIntent(in rotationIntent, t testing.TB) {
+	b, err := json.Marshal(in)
+	isNilUp(err, t, 1)
+	isNilUp(ioutil.WriteFile(intentName(in.Source), b, 0600), t, 1)
+}
+
+func TestCrashSafeRotate(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCrashSafeRotate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	for _, async := range []bool{false, true} {
+		os.Remove(filename)
+		l := &Logger{
+			Filename:        filename,
+			CrashSafeRotate: true,
+			AsyncRotate:     async,
+		}
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		_, err = l.Write([]byte("foo!"))
+		isNil(err, t)
+		isNil(l.Close(), t)
+		existsWithContent(backupFile(dir), []byte("boo!"), t)
+		existsWithContent(filename, []byte("foo!"), t)
+		notExist(intentName(filename), t)
+	}
+}
+
+func TestCrashSafeRotateLinked(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCrashSafeRotateLinked", t)
+	defer os.RemoveAll(dir)
+
+	// the log file was linked to its backup, and then the process died.
+	filename := logFile(dir)
+	backup := backupFile(dir)
+	isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+	isNil(os.Link(filename, backup), t)
+	writeIntent(rotationIntent{Source: filename, Backup: backup}, t)
+
+	l := &Logger{
+		Filename:        filename,
+		CrashSafeRotate: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("foo!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("foo!"), t)
+	existsWithContent(backup, []byte("boo!"), t)
+	notExist(intentName(filename), t)
+}
+
+func TestCrashSafeRotateHandoff(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCrashSafeRotateHandoff", t)
+	defer os.RemoveAll(dir)
+
+	// writes had moved on to the new log file, but the old one was still in
+	// place, when the process died.
+	filename := logFile(dir)
+	backup := backupFile(dir)
+	next := nextName(filename)
+	isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+	isNil(ioutil.WriteFile(next, []byte("foo!"), 0644), t)
+	writeIntent(rotationIntent{Source: filename, Backup: backup, Next: next}, t)
+
+	l := &Logger{
+		Filename:        filename,
+		CrashSafeRotate: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("bar!"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("foo!bar!"), t)
+	existsWithContent(backup, []byte("boo!"), t)
+	notExist(next, t)
+	notExist(intentName(filename), t)
+}
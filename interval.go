@@ -0,0 +1,40 @@
+package lumberjack
+
+import (
+	"os"
+	"time"
+)
+
+// opened notes that a new log file has just been opened, starting the clock
+// for RotateInterval and RotateDaily, and the count for MaxWrites.
+func (l *Logger) opened() {
+	l.writes = 0
+	l.openedAt = currentTime()
+	l.dailyFrom = l.openedAt
+	l.dailyNext = time.Time{}
+}
+
+// openedExisting is opened for a log file that was already there, described
+// by info, so that RotateDaily goes by when it was last written to.
+func (l *Logger) openedExisting(info os.FileInfo) {
+	l.opened()
+	if info.Size() > 0 && info.ModTime().Before(l.dailyFrom) {
+		l.dailyFrom = info.ModTime()
+	}
+}
+
+// rotateDue reports whether the log file has been open for RotateInterval
+// and has something in it.  The time of opening comes from time.Now, so
+// subtracting it uses the monotonic clock rather than the wall clock, which
+// can jump.
+func (l *Logger) rotateDue() bool {
+	if l.RotateInterval <= 0 || l.file == nil || l.unused {
+		return false
+	}
+	return currentTime().Sub(l.openedAt) >= l.RotateInterval
+}
+
+// writesDue reports whether the log file has had MaxWrites writes.
+func (l *Logger) writesDue() bool {
+	return l.MaxWrites > 0 && l.file != nil && l.writes >= l.MaxWrites
+}
@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRotateInterval(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRotateInterval", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		RotateInterval: time.Hour,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, []byte("boo!boo!"), t)
+	fileCount(dir, 1, t)
+
+	newFakeTime()
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(filename, b2, t)
+	existsWithContent(backupFile(dir), []byte("boo!boo!"), t)
+	fileCount(dir, 2, t)
+
+	// a clock that goes backwards doesn't cause a rotation.
+	fakeCurrentTime = fakeCurrentTime.Add(-24 * time.Hour)
+	_, err = l.Write(b2)
+	isNil(err, t)
+	fileCount(dir, 2, t)
+}
+
+func TestMaxWrites(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMaxWrites", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var reasons []RotateReason
+	l := &Logger{
+		Filename:  filename,
+		MaxWrites: 2,
+		OnRotate:  func(_ string, r RotateReason) { reasons = append(reasons, r) },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("one\ntwo\n"), t)
+	fileCount(dir, 1, t)
+
+	newFakeTime()
+	_, err = l.Write([]byte("three\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("three\n"), t)
+	existsWithContent(backupFile(dir), []byte("one\ntwo\n"), t)
+	equals([]RotateReason{RotateWrites}, reasons, t)
+
+	// a manual rotation starts the count again.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("four\n"))
+	isNil(err, t)
+	_, err = l.Write([]byte("five\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("four\nfive\n"), t)
+	fileCount(dir, 3, t)
+}
+
+func TestKeepSchedule(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestKeepSchedule", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		RotateInterval: time.Hour,
+		KeepSchedule:   true,
+	}
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	next := fakeTime().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	existsWithContent(scheduleName(filename), []byte(next+"\n"), t)
+
+	// a restarted Logger carries on with the schedule, rather than starting
+	// the hour again.
+	fakeCurrentTime = fakeCurrentTime.Add(40 * time.Minute)
+	l = &Logger{
+		Filename:       filename,
+		RotateInterval: time.Hour,
+		KeepSchedule:   true,
+	}
+	defer l.Close()
+	_, err = l.Write(b)
+	isNil(err, t)
+	fileCount(dir, 2, t)
+
+	fakeCurrentTime = fakeCurrentTime.Add(30 * time.Minute)
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+	exists(backupFile(dir), t)
+	next = fakeTime().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	existsWithContent(scheduleName(filename), []byte(next+"\n"), t)
+}
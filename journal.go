@@ -0,0 +1,38 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// journalSuffix is added to the log file's name to make the name of the
+// journal.
+const journalSuffix = ".rotations"
+
+// writeJournal appends e to the journal if Journal is set and e is about a
+// rotation, compression or removal.  The journal is opened for each event,
+// so it can be rotated or removed at any time.
+func (l *Logger) writeJournal(e Event) {
+	if !l.Journal {
+		return
+	}
+	switch e.Type {
+	case EventRotate, EventCompress, EventRemove, EventPurge:
+	default:
+		return
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	b = append(b, '\n')
+
+	l.journalMu.Lock()
+	defer l.journalMu.Unlock()
+	f, err := os.OpenFile(l.filename()+journalSuffix, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	_, _ = f.Write(b)
+	_ = f.Close()
+}
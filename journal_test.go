@@ -0,0 +1,53 @@
+package lumberjack
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestJournal", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		MaxBackups:    1,
+		Compress:      true,
+		Journal:       true,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+	isNil(l.CleanNow(), t)
+
+	f, err := os.Open(logFile(dir) + journalSuffix)
+	isNil(err, t)
+	defer f.Close()
+	var types []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		var e struct {
+			Type     string `json:"type"`
+			Filename string `json:"filename"`
+			Size     int64  `json:"size"`
+		}
+		isNil(json.Unmarshal(s.Bytes(), &e), t)
+		assert(e.Filename != "", t, "journal entry with no filename: %s", s.Bytes())
+		types = append(types, e.Type)
+	}
+	isNil(s.Err(), t)
+	equals("[rotate rotate compress remove]", fmt.Sprint(types), t)
+}
@@ -0,0 +1,18 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// linkBackup makes a hard link to the backup name in LinkDir, if it's set.
+func (l *Logger) linkBackup(name string) {
+	if l.LinkDir == "" {
+		return
+	}
+	dst := filepath.Join(l.LinkDir, filepath.Base(name))
+	if err := os.Link(name, dst); err != nil {
+		l.emit(Event{Type: EventLink, Filename: name, Err: fmt.Errorf("can't link backup into %s: %w", l.LinkDir, err)})
+	}
+}
@@ -0,0 +1,83 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestLinkDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestLinkDir", t)
+	defer os.RemoveAll(dir)
+	links := filepath.Join(dir, "archive")
+	isNil(os.Mkdir(links, 0755), t)
+
+	var (
+		mu     sync.Mutex
+		events []Event
+	)
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		MaxBackups:    1,
+		Compress:      true,
+		ManualCleanup: true,
+		LinkDir:       links,
+		OnEvent: func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	link := filepath.Join(links, filepath.Base(backup))
+	existsWithContent(link, b, t)
+
+	// compressing the backup removes only the Logger's own name for it
+	isNil(l.CleanNow(), t)
+	notExist(backup, t)
+	exists(backup+compressSuffix, t)
+	existsWithContent(link, b, t)
+
+	// neither does retention touch the link
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.CleanNow(), t)
+	notExist(backup+compressSuffix, t)
+	existsWithContent(link, b, t)
+	fileCount(links, 3, t)
+
+	mu.Lock()
+	for _, e := range events {
+		isNil(e.Err, t)
+	}
+	mu.Unlock()
+
+	// a missing LinkDir is reported but doesn't stop rotation
+	isNil(os.RemoveAll(links), t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	var failed bool
+	mu.Lock()
+	for _, e := range events {
+		failed = failed || (e.Type == EventLink && e.Err != nil)
+		// the rotation itself still succeeded.
+		if e.Type == EventRotate {
+			isNil(e.Err, t)
+		}
+	}
+	mu.Unlock()
+	assert(failed, t, "expected a failed link to be reported")
+}
@@ -3,7 +3,10 @@
 package lumberjack
 
 import (
+	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"syscall"
 	"testing"
 	"time"
@@ -48,12 +51,6 @@ func TestMaintainMode(t *testing.T) {
 
 func TestMaintainOwner(t *testing.T) {
 	fakeFS := newFakeFS()
-	osChown = fakeFS.Chown
-	osStat = fakeFS.Stat
-	defer func() {
-		osChown = os.Chown
-		osStat = os.Stat
-	}()
 	currentTime = fakeTime
 	dir := makeTempDir("TestMaintainOwner", t)
 	defer os.RemoveAll(dir)
@@ -68,6 +65,8 @@ func TestMaintainOwner(t *testing.T) {
 		Filename:   filename,
 		MaxBackups: 1,
 		MaxSize:    100, // megabytes
+		Chown:      fakeFS.Chown,
+		Stat:       fakeFS.Stat,
 	}
 	defer l.Close()
 	b := []byte("boo!")
@@ -131,12 +130,6 @@ func TestCompressMaintainMode(t *testing.T) {
 
 func TestCompressMaintainOwner(t *testing.T) {
 	fakeFS := newFakeFS()
-	osChown = fakeFS.Chown
-	osStat = fakeFS.Stat
-	defer func() {
-		osChown = os.Chown
-		osStat = os.Stat
-	}()
 	currentTime = fakeTime
 	dir := makeTempDir("TestCompressMaintainOwner", t)
 	defer os.RemoveAll(dir)
@@ -152,6 +145,8 @@ func TestCompressMaintainOwner(t *testing.T) {
 		Filename:   filename,
 		MaxBackups: 1,
 		MaxSize:    100, // megabytes
+		Chown:      fakeFS.Chown,
+		Stat:       fakeFS.Stat,
 	}
 	defer l.Close()
 	b := []byte("boo!")
@@ -175,6 +170,58 @@ func TestCompressMaintainOwner(t *testing.T) {
 	equals(666, fakeFS.files[filename2+compressSuffix].gid, t)
 }
 
+func TestChownWithoutOwner(t *testing.T) {
+	fakeFS := newFakeFS()
+	currentTime = fakeTime
+	dir := makeTempDir("TestChownWithoutOwner", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_RDWR, 0644)
+	isNil(err, t)
+	f.Close()
+
+	l := &Logger{
+		Compress:      true,
+		Filename:      filename,
+		MaxBackups:    1,
+		MaxSize:       100, // megabytes
+		ManualCleanup: true,
+		Chown:         fakeFS.Chown,
+		Stat: func(name string) (os.FileInfo, error) {
+			info, err := os.Stat(name)
+			if err != nil {
+				return nil, err
+			}
+			return noOwnerInfo{info}, nil
+		},
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+
+	newFakeTime()
+
+	isNil(l.Rotate(), t)
+	isNil(l.CleanNow(), t)
+
+	// the files are made as usual, just without changing their owner.
+	existsWithContent(filename, []byte{}, t)
+	exists(backupFile(dir)+compressSuffix, t)
+	equals(0, len(fakeFS.files), t)
+}
+
+// noOwnerInfo is a FileInfo, like those from fstest or afero, that has no
+// *syscall.Stat_t behind it.
+type noOwnerInfo struct {
+	os.FileInfo
+}
+
+func (noOwnerInfo) Sys() interface{} { return nil }
+
 type fakeFile struct {
 	uid int
 	gid int
@@ -203,3 +250,333 @@ func (fs *fakeFS) Stat(name string) (os.FileInfo, error) {
 	stat.Gid = 666
 	return info, nil
 }
+
+func TestAppendOnly(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestAppendOnly", t)
+	defer os.RemoveAll(dir)
+
+	// not every filesystem supports the attribute, and setting it takes
+	// privileges.
+	probe, err := os.Create(logFile(dir) + ".probe")
+	isNil(err, t)
+	err = setAppendOnly(probe, true)
+	if err == nil {
+		err = setAppendOnly(probe, false)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	if err != nil {
+		t.Skipf("can't set the append-only attribute here: %v", err)
+	}
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:   filename,
+		AppendOnly: true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+
+	// the live file can't be truncated or removed.
+	notNil(os.Truncate(filename, 0), t)
+	notNil(os.Remove(filename), t)
+
+	// but rotation still works.
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+	existsWithContent(backupFile(dir), b, t)
+	isNil(os.Truncate(backupFile(dir), 0), t)
+
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	isNil(os.Truncate(filename, 0), t)
+}
+
+func TestPassthroughFIFO(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestPassthroughFIFO", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	isNil(syscall.Mkfifo(filename, 0600), t)
+
+	read := make(chan []byte)
+	go func() {
+		f, err := os.Open(filename)
+		if err != nil {
+			read <- nil
+			return
+		}
+		defer f.Close()
+		b, _ := ioutil.ReadAll(f)
+		read <- b
+	}()
+
+	l := &Logger{
+		Filename:   filename,
+		MaxSize:    5,
+		MaxBackups: 1,
+		Compress:   true,
+	}
+	isNil(l.Preflight(), t)
+
+	// writes bigger than MaxSize are fine, and neither they nor Rotate move
+	// the FIFO aside.
+	b := []byte("more than five\n")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	equals(string(b)+string(b)+string(b), string(<-read), t)
+	fileCount(dir, 1, t)
+}
+
+func TestRenameUnchecked(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRenameUnchecked", t)
+	defer os.RemoveAll(dir)
+
+	// as on an architecture without renameat2.
+	defer func(n uintptr) { sysRenameat2 = n }(sysRenameat2)
+	sysRenameat2 = 0
+
+	var events []Event
+	l := &Logger{
+		Filename: logFile(dir),
+		OnEvent:  func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+	for i := 0; i < 2; i++ {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+
+	// the fallback is reported, but only once.
+	var unchecked []Event
+	for _, e := range events {
+		if e.Type == EventRenameUnchecked {
+			unchecked = append(unchecked, e)
+		}
+	}
+	equals(1, len(unchecked), t)
+	notNil(unchecked[0].Err, t)
+}
+
+func TestAtomicCreate(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestAtomicCreate", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:     filename,
+		AtomicCreate: true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+
+	err = os.Chmod(filename, 0640)
+	isNil(err, t)
+	newFakeTime()
+
+	err = l.Rotate()
+	isNil(err, t)
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+
+	existsWithContent(filename, b2, t)
+	existsWithContent(backupFile(dir), b, t)
+	fileCount(dir, 2, t)
+	info, err := os.Stat(filename)
+	isNil(err, t)
+	equals(os.FileMode(0640), info.Mode(), t)
+}
+
+func TestAtomicCreateChown(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestAtomicCreateChown", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	fakeFS := newFakeFS()
+	l := &Logger{
+		Filename:     filename,
+		AtomicCreate: true,
+		Chown:        fakeFS.Chown,
+		Stat:         fakeFS.Stat,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the new file is given its owner through Chown.
+	equals(555, fakeFS.files[filename].uid, t)
+	equals(666, fakeFS.files[filename].gid, t)
+
+	// and a FileInfo with no owner behind it is left alone.
+	fakeFS = newFakeFS()
+	l.Chown = fakeFS.Chown
+	l.Stat = func(name string) (os.FileInfo, error) {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		return noOwnerInfo{info}, nil
+	}
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	b := []byte("foooooo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+	equals(0, len(fakeFS.files), t)
+}
+
+func TestHarden(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHarden", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	target := filepath.Join(dir, "target")
+	isNil(ioutil.WriteFile(target, []byte("secret"), 0600), t)
+
+	// a symlinked log file is refused.
+	isNil(os.Symlink(target, filename), t)
+	l := &Logger{
+		Filename: filename,
+		Compress: true,
+		Harden:   true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrUnsafePath), t, "expected ErrUnsafePath, got %v", err)
+	existsWithContent(target, []byte("secret"), t)
+	isNil(os.Remove(filename), t)
+
+	// so is a directory anyone can write to.
+	isNil(os.Chmod(dir, 0777), t)
+	_, err = l.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrUnsafePath), t, "expected ErrUnsafePath, got %v", err)
+	isNil(os.Chmod(dir, 0700), t)
+
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+
+	// compression won't write through a symlink planted at the backup's
+	// compressed name.
+	newFakeTime()
+	backup := backupFile(dir)
+	isNil(os.Symlink(target, backup+compressSuffix), t)
+	isNil(l.Rotate(), t)
+	notNil(l.CleanNow(), t)
+	existsWithContent(target, []byte("secret"), t)
+	existsWithContent(backup, b, t)
+}
+
+func TestHardenSymlinkedDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHardenSymlinkedDir", t)
+	defer os.RemoveAll(dir)
+
+	// a log directory that is a symlink is refused.
+	real := filepath.Join(dir, "real")
+	isNil(os.Mkdir(real, 0700), t)
+	link := filepath.Join(dir, "link")
+	isNil(os.Symlink(real, link), t)
+	l := &Logger{
+		Filename: filepath.Join(link, "foobar.log"),
+		Harden:   true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrUnsafePath), t, "expected ErrUnsafePath, got %v", err)
+	fileCount(real, 0, t)
+}
+
+func TestHardenAncestors(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestHardenAncestors", t)
+	defer os.RemoveAll(dir)
+
+	// a directory further up that anyone could rename things in.
+	shared := filepath.Join(dir, "shared")
+	logs := filepath.Join(shared, "app", "logs")
+	isNil(os.MkdirAll(logs, 0700), t)
+	isNil(os.Chmod(shared, 0777), t)
+	l := &Logger{
+		Filename: filepath.Join(logs, "foobar.log"),
+		Harden:   true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	assert(errors.Is(err, ErrUnsafePath), t, "expected ErrUnsafePath, got %v", err)
+	fileCount(logs, 0, t)
+
+	// with the sticky bit, as on /tmp, nobody can rename what isn't theirs.
+	isNil(os.Chmod(shared, 0777|os.ModeSticky), t)
+	b := []byte("boo!")
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(l.Filename, b, t)
+}
+
+func TestFollowSymlink(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestFollowSymlink", t)
+	defer os.RemoveAll(dir)
+
+	// the symlink points at a file that doesn't exist yet.
+	link := filepath.Join(dir, "current")
+	target := logFile(dir)
+	isNil(os.Symlink(filepath.Base(target), link), t)
+	l := &Logger{
+		Filename:      link,
+		FollowSymlink: true,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	existsWithContent(target, b, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	existsWithContent(backupFile(dir), b, t)
+	existsWithContent(target, b2, t)
+	info, err := os.Lstat(link)
+	isNil(err, t)
+	assert(info.Mode()&os.ModeSymlink != 0, t, "expected %s to still be a symlink", link)
+	existsWithContent(link, b2, t)
+	fileCount(dir, 3, t)
+}
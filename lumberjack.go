@@ -23,6 +23,7 @@ package lumberjack
 
 import (
 	"compress/gzip"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -32,6 +33,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -79,13 +81,94 @@ var _ io.WriteCloser = (*Logger)(nil)
 type Logger struct {
 	// Filename is the file to write logs to.  Backup log files will be retained
 	// in the same directory.  It uses <processname>-lumberjack.log in
-	// os.TempDir() if empty.
+	// os.TempDir() if empty.  If it is a character device, a FIFO, /dev/stdout
+	// or /dev/stderr, the Logger just writes to it, with no rotation, size
+	// tracking or cleanup, since there is nothing that can be rotated.
 	Filename string `json:"filename" yaml:"filename"`
 
+	// ExpandEnv determines if ${VAR} and $VAR in Filename and
+	// FallbackFilename are replaced by the values of those environment
+	// variables, as in "/var/log/${SERVICE_NAME}/app.log".  If a variable
+	// isn't set, opening the log file fails with an error wrapping
+	// ErrUnsetEnv.
+	ExpandEnv bool `json:"expandenv" yaml:"expandenv"`
+
+	// TimedFilename determines if the parts of the base name of Filename
+	// between braces are time layouts, as for time.Format, that are filled
+	// in with the current time, so that there is a new log file for each day
+	// with "app-{2006-01-02}.log", say.  When the name changes, the Logger
+	// switches to the new file and the old one is left to be compressed and
+	// removed like any other backup.  The time is in UTC unless LocalTime is
+	// set.  The parts of the name outside braces shouldn't themselves look
+	// like time layouts.
+	TimedFilename bool `json:"timedfilename" yaml:"timedfilename"`
+
+	// FollowSymlink determines if, when Filename is a symlink, as with
+	// "/var/log/app/current", the file it points to is the log file, so that
+	// it is the target that is rotated, with its backups named after it and
+	// kept beside it, and the symlink goes on pointing at the live log file.
+	// The symlink is looked up again each time the log file is opened.  It
+	// may point at a file that doesn't exist yet.  Without FollowSymlink, the
+	// symlink itself is moved aside when the log file is rotated.
+	FollowSymlink bool `json:"followsymlink" yaml:"followsymlink"`
+
+	// CompressActive determines if the log file is written as a gzip stream,
+	// so that logs never sit uncompressed on disk, which is useful for very
+	// verbose logging.  The log file and its backups then have ".gz" added to
+	// their names, MaxSize applies to the compressed size, and Compress has
+	// nothing left to do.  Each time the log file is opened a new gzip member
+	// is started, and rotation and Close finish it.  AsyncRotate is ignored.
+	CompressActive bool `json:"compressactive" yaml:"compressactive"`
+
+	// CompressFlushInterval is how soon what has been written with
+	// CompressActive set is flushed through to the log file, where it can be
+	// read.  Flushing more often makes compression a little worse.  It
+	// defaults to one second.
+	CompressFlushInterval time.Duration `json:"compressflushinterval" yaml:"compressflushinterval"`
+
 	// MaxSize is the maximum size in megabytes of the log file before it gets
 	// rotated. It defaults to 100 megabytes.
 	MaxSize int `json:"maxsize" yaml:"maxsize"`
 
+	// RotateInterval is how long the Logger writes to a log file before
+	// rotating it, whatever its size.  It is measured from when the Logger
+	// opened the file, by the monotonic clock, so setting the system clock,
+	// by hand or by NTP, neither brings rotation forward nor holds it back;
+	// the wall clock only goes into the backups' names, and into the
+	// schedule that KeepSchedule records.  As with MaxSize, the file is only
+	// rotated by a write, and an empty file is never rotated.  The default
+	// is to rotate by size alone.
+	RotateInterval time.Duration `json:"rotateinterval" yaml:"rotateinterval"`
+
+	// KeepSchedule determines if the time that RotateInterval next calls for
+	// a rotation is recorded in a hidden file beside the log file, so that a
+	// restarted program carries on with the schedule rather than starting
+	// it again, which for a program restarted more often than
+	// RotateInterval would mean never rotating.  A recorded time can only
+	// bring rotation forward, and is measured by the wall clock.
+	KeepSchedule bool `json:"keepschedule" yaml:"keepschedule"`
+
+	// MaxWrites is the maximum number of calls to Write that go into a log
+	// file before it is rotated, for when each Write is one record and each
+	// file should hold a fixed number of them.  Only writes made since the
+	// Logger opened the file count, and with BatchWindow, all the writes in
+	// a batch count as one.  The default is not to count writes.
+	MaxWrites int `json:"maxwrites" yaml:"maxwrites"`
+
+	// RotateDaily determines if the log file is rotated once a day, at the
+	// time of day RotateDailyAt, reckoned in TimeZone.  The first write at or
+	// after that time rotates it, as long as the file isn't empty.  On a day
+	// when the clock is set back over that time, as when daylight saving time
+	// ends, rotation happens the first time the clock reads it, and not
+	// again.  On a day when the clock skips over it, as when daylight saving
+	// time begins, rotation happens when it skips, so no day goes without.
+	// Write returns an error if TimeZone can't be loaded.
+	RotateDaily bool `json:"rotatedaily" yaml:"rotatedaily"`
+
+	// RotateDailyAt is the time of day, as an offset from midnight, that
+	// RotateDaily rotates at.  It defaults to midnight.
+	RotateDailyAt time.Duration `json:"rotatedailyat" yaml:"rotatedailyat"`
+
 	// MaxAge is the maximum number of days to retain old log files based on the
 	// timestamp encoded in their filename.  Note that a day is defined as 24
 	// hours and may not exactly correspond to calendar days due to daylight
@@ -98,83 +181,872 @@ type Logger struct {
 	// deleted.)
 	MaxBackups int `json:"maxbackups" yaml:"maxbackups"`
 
+	// MaxBackupsPerDay is the maximum number of old log files to retain from
+	// any one day, keeping the newest of each day, so that a burst of
+	// rotations in one bad hour doesn't push out the history of quieter
+	// days.  Days are reckoned in the TimeZone.  The default is not to limit
+	// backups per day.
+	MaxBackupsPerDay int `json:"maxbackupsperday" yaml:"maxbackupsperday"`
+
+	// PruneExclude lists patterns, as for filepath.Match, of the file names
+	// of backups that cleanup leaves alone, such as ones an operator has
+	// marked to keep or another tool has pinned.  Those backups are never
+	// compressed or removed, by the mill, DiskBudget, DiskPressure or
+	// PurgeOnNoSpace, and don't count toward MaxBackups or any other limit.
+	// A malformed pattern matches nothing.
+	PruneExclude []string `json:"pruneexclude" yaml:"pruneexclude"`
+
+	// PruneExcludeFunc, if set, is called with the file name of each backup,
+	// and cleanup leaves alone the ones it returns true for, as with
+	// PruneExclude.  It is called from the goroutine doing the cleanup.
+	PruneExcludeFunc func(name string) bool `json:"-" yaml:"-"`
+
+	// MaxTotalSize is the maximum size in megabytes of the log file and all of
+	// its backups put together.  When they grow beyond it, the oldest backups
+	// are deleted, however the growth came about, so manual rotations can't
+	// push disk usage past it.  The log file itself is never deleted.  The
+	// default is not to limit the total size.
+	MaxTotalSize int `json:"maxtotalsize" yaml:"maxtotalsize"`
+
 	// LocalTime determines if the time used for formatting the timestamps in
 	// backup files is the computer's local time.  The default is to use UTC
 	// time.
 	LocalTime bool `json:"localtime" yaml:"localtime"`
 
+	// CalendarDays determines if MaxAge counts calendar days rather than
+	// 24 hour periods.  When it is set, backups rotated today and on the
+	// MaxAge-1 days before are retained, and older ones deleted.  Days are
+	// reckoned in TimeZone.
+	CalendarDays bool `json:"calendardays" yaml:"calendardays"`
+
+	// TimeZone is the name of the IANA time zone, such as "Europe/Paris",
+	// that CalendarDays uses to decide when days begin.  It defaults to the
+	// computer's local time zone if LocalTime is set, and UTC otherwise.
+	TimeZone string `json:"timezone" yaml:"timezone"`
+
+	// Budget, if set, is a disk space limit shared with other Loggers.  See
+	// DiskBudget.
+	Budget *DiskBudget `json:"-" yaml:"-"`
+
+	// GFS, if set, thins out backups using a grandfather-father-son scheme
+	// on top of MaxBackups and MaxAge.  See GFSPolicy.
+	GFS *GFSPolicy `json:"gfs" yaml:"gfs"`
+
+	// Thin, if set, thins out older backups to one per hour, day or other
+	// interval, on top of the other retention settings.  See ThinPolicy.
+	Thin *ThinPolicy `json:"thin" yaml:"thin"`
+
+	// MaintenanceWindow, if set, restricts the background compression and
+	// removal of old log files to a span of each day.  Cleanup asked for
+	// outside the window waits for it to open, and is dropped by Close.
+	// CleanNow isn't affected.  See MaintenanceWindow.
+	MaintenanceWindow *MaintenanceWindow `json:"maintenancewindow" yaml:"maintenancewindow"`
+
 	// Compress determines if the rotated log files should be compressed
-	// using gzip. The default is not to perform compression.
+	// using gzip. The default is not to perform compression.  Unless
+	// CompressKeepName is set, no file name or time is recorded in the gzip
+	// header, so backups with the same content compress to the same bytes,
+	// as deduplicating and content-addressed stores like.  Backups left
+	// uncompressed from before Compress was set, by an earlier configuration,
+	// are compressed along with the rest by the first round of cleanup, which
+	// happens when the log file is first opened, so the directory doesn't stay
+	// half compressed.
 	Compress bool `json:"compress" yaml:"compress"`
 
+	// CompressKeepName determines if the gzip header of a compressed backup
+	// records the backup's original name and modification time, so that
+	// gunzip -N restores it exactly as it was.  For zip backups, the
+	// modification time is recorded.  Backups with the same content then no
+	// longer compress to the same bytes.
+	CompressKeepName bool `json:"compresskeepname" yaml:"compresskeepname"`
+
+	// CompressMinSize is the size in bytes below which Compress leaves a
+	// backup uncompressed, since compressing a tiny one, from a forced
+	// rotation say, saves little and makes it harder to look at.  The
+	// default is to compress every backup.
+	CompressMinSize int64 `json:"compressminsize" yaml:"compressminsize"`
+
+	// CompressBufferSize is the size in bytes of the buffer that backups are
+	// read through while they are compressed.  Compressing very large
+	// backups on fast disks goes quicker with a buffer of a megabyte or so.
+	// It defaults to 32 kilobytes.
+	CompressBufferSize int `json:"compressbuffersize" yaml:"compressbuffersize"`
+
+	// CompressChunkSize, if set, is the size in megabytes of the chunks that
+	// gzip backups are compressed in, each a separate gzip member, which
+	// gzip and Reader read back as one.  How far compression has got is
+	// recorded after each chunk, so that if it is interrupted, by a crash or
+	// a restart, it carries on from the last complete chunk rather than
+	// starting again, which matters for backups of many gigabytes.  The
+	// default is to compress each backup in one piece.
+	CompressChunkSize int `json:"compresschunksize" yaml:"compresschunksize"`
+
+	// SyncCompressed determines if each compressed backup, and the directory
+	// entry for it, is flushed to stable storage before the uncompressed
+	// backup is removed, so that losing power just after compression can't
+	// lose both.  It makes compression slower.
+	SyncCompressed bool `json:"synccompressed" yaml:"synccompressed"`
+
+	// WorkDir, if set, is a directory in which compressed backups are
+	// written while they are being made, and from which they are renamed
+	// into place once complete, so that nothing watching the log file's
+	// directory ever picks up a half-written one.  A relative WorkDir is
+	// relative to the log file's directory, and it must be on the same
+	// filesystem.  It is created if it doesn't exist.  The default is to
+	// write compressed backups in place.
+	WorkDir string `json:"workdir" yaml:"workdir"`
+
+	// CompressFormat is the format that Compress uses: "gzip", which adds
+	// ".gz" to the backups' names, "zip", which adds ".zip" and makes
+	// archives that Windows can open without extra tools, or one added with
+	// RegisterFormat, such as brotli.  The default is "gzip".  Backups in
+	// any of these formats are recognized for retention and reading,
+	// whatever the setting.
+	CompressFormat string `json:"compressformat" yaml:"compressformat"`
+
+	// WriteTimeout, if set, is how long Write may take before giving up and
+	// returning ErrWriteTimeout, so that a hung filesystem can't hang the
+	// goroutines that are logging.  A write that times out before it gets
+	// going is dropped.  One that is already under way carries on in the
+	// background, and until it finishes, further writes fail immediately with
+	// ErrWriteTimeout, and their data is lost.  The default is to wait as
+	// long as it takes.
+	WriteTimeout time.Duration `json:"writetimeout" yaml:"writetimeout"`
+
+	// ReconcileInterval, if set, is how often to check the log file on disk
+	// against what the Logger thinks it has written, to catch other processes
+	// touching it.  If the file has been removed or replaced, the Logger
+	// opens the one now at Filename, and if its size has changed, the Logger
+	// adopts the new size.  Either way, an EventReconcile is reported.  The
+	// check happens during Write.  The default is never to check.
+	ReconcileInterval time.Duration `json:"reconcileinterval" yaml:"reconcileinterval"`
+
+	// Heartbeat, if set, is how long the log file may go without being
+	// written to before HeartbeatLine is written to it, so that monitoring
+	// that alerts on a log file that hasn't changed in a while stays quiet
+	// for a service that has nothing to say, and so that time-based
+	// rotation happens on schedule.  Heartbeats don't count toward
+	// MaxWrites and aren't passed on to Metrics.  The default is not to
+	// write heartbeats.
+	Heartbeat time.Duration `json:"heartbeat" yaml:"heartbeat"`
+
+	// HeartbeatLine is the line written for Heartbeat, with placeholders
+	// filled in as for Banner.  It defaults to
+	// "heartbeat {2006-01-02T15:04:05Z07:00}".
+	HeartbeatLine string `json:"heartbeatline" yaml:"heartbeatline"`
+
+	// BatchWindow, if set, is how long a small Write waits for other Writes
+	// to join it, so that they can all be made to the log file at once.  For
+	// services with many goroutines logging at once, this means far fewer
+	// system calls, at the cost of each Write taking up to BatchWindow
+	// longer.  Write still waits until its data has been written, and writes
+	// are never split across batches.  The default is not to batch writes.
+	BatchWindow time.Duration `json:"batchwindow" yaml:"batchwindow"`
+
+	// ManualCleanup determines if compression and removal of old log files is
+	// left to the caller, who should call CleanNow on their own schedule.
+	// Uploads then happen during CleanNow too, so cleanup and uploads never
+	// run on a goroutine of the Logger's own.  Other options still do:
+	// WebhookURL, Heartbeat, DiskPressure, AsyncRotate, SpareFile,
+	// WriteTimeout and WriteContext, and the flush timer of CompressActive.
+	// The default is to clean up in the background after each rotation.
+	ManualCleanup bool `json:"manualcleanup" yaml:"manualcleanup"`
+
+	// ReopenOnWrite determines if Write, Rotate and Adopt after Close reopen
+	// the log file and carry on, rather than returning ErrClosed, for a
+	// Logger that is closed to let go of its file for a while, such as when
+	// the log file has been moved aside by another program.
+	ReopenOnWrite bool `json:"reopenonwrite" yaml:"reopenonwrite"`
+
+	// FallbackFilename is a file to write logs to when Filename can't be
+	// opened or written, for example because its filesystem has become
+	// read-only.  Rotation and cleanup continue as normal in the fallback
+	// file's directory, and the Logger periodically tries Filename again,
+	// switching back once it works.  The default is to have no fallback.
+	FallbackFilename string `json:"fallbackfilename" yaml:"fallbackfilename"`
+
+	// StderrFallback determines if log data that can't be written to a file,
+	// even after trying FallbackFilename, is written to stderr instead, each
+	// write prefixed with the log file's name, so that it isn't lost
+	// altogether.  Write then reports success.  The default is to drop the
+	// data and return the error.
+	StderrFallback bool `json:"stderrfallback" yaml:"stderrfallback"`
+
+	// MemoryBuffer is the maximum size in megabytes of log data to hold in
+	// memory when it can't be written to a file, even after trying
+	// FallbackFilename, so that it can be written to the log file once that
+	// works again, ahead of any later writes.  Write then reports success.
+	// If more than that can't be written, the oldest data is dropped, and how
+	// much is reported by an EventReplay event once the rest is written.  It
+	// takes precedence over StderrFallback.  The default is not to buffer.
+	MemoryBuffer int `json:"memorybuffer" yaml:"memorybuffer"`
+
+	// StrictErrors determines if an error from background compression,
+	// removal or chown of old log files is also returned, wrapped, by the
+	// next Write that otherwise succeeds.  Each error is returned only once,
+	// and that Write's data has still been written.  The default is to only
+	// report such errors through LastError and OnEvent.
+	StrictErrors bool `json:"stricterrors" yaml:"stricterrors"`
+
+	// ReadOnlyBackups determines if backups have their write permissions
+	// removed as soon as they are finished, which is right after rotation,
+	// or after compression if Compress is set.  This makes them harder to
+	// tamper with by accident.  They can still be removed by the cleanup of
+	// old log files.
+	ReadOnlyBackups bool `json:"readonlybackups" yaml:"readonlybackups"`
+
+	// AppendOnly determines if, on Linux, the log file is given the
+	// append-only attribute (as with chattr +a) while it is open, so that
+	// not even root can truncate or replace it by accident.  The attribute
+	// is cleared again when the file is rotated or closed.  Setting it needs
+	// the CAP_LINUX_IMMUTABLE capability, and opening the log file fails
+	// without it, as it does on Linux architectures where lumberjack can't
+	// set the attribute (those other than amd64, arm64, 386, arm, riscv64,
+	// loong64 and s390x).  AppendOnly is ignored on other platforms.
+	AppendOnly bool `json:"appendonly" yaml:"appendonly"`
+
+	// AtomicCreate determines if, on Linux, new log files are made without a
+	// name and only linked into place once they are ready, and the old log
+	// file is linked to its backup name before the new one replaces it.  This
+	// way a crash during rotation can never leave the log file missing or
+	// half made.  If the filesystem doesn't support it, or on other
+	// platforms, log files are created the usual way.
+	AtomicCreate bool `json:"atomiccreate" yaml:"atomiccreate"`
+
+	// Harden guards against symlink attacks in a shared log directory.  If
+	// it is set, the log file won't be written if it or its directory is
+	// writable by everyone or owned by anyone but this process's user or
+	// root, or is a symlink, or if a directory further up could be swapped
+	// out by someone else, by being owned by them or writable by everyone
+	// without the sticky bit; log files and compressed backups are opened
+	// without following symlinks, and the log file isn't moved to a backup
+	// name that is a symlink.  With FollowSymlink, the
+	// checks apply to the file that Filename points to, and its directory.
+	// Harden only has an effect on Unix.
+	Harden bool `json:"harden" yaml:"harden"`
+
+	// CrashSafeRotate determines if each rotation is recorded in a small
+	// file next to the log file, synced to disk, before the log file is
+	// moved aside, and the record removed once the new log file is in
+	// place.  If the process dies part way through, the next time the log
+	// file is opened the rotation is finished, so that the log file is never
+	// left missing, or sharing its data with its backup.
+	CrashSafeRotate bool `json:"crashsaferotate" yaml:"crashsaferotate"`
+
+	// NumericBackups determines if backups are named the way logrotate names
+	// them, by adding a number to the log file name, rather than a
+	// timestamp.  The most recent backup of app.log is app.log.1, and each
+	// rotation renumbers the others, so app.log.1 becomes app.log.2, and
+	// app.log.2.gz becomes app.log.3.gz.  MaxAge goes by the backups'
+	// modification times.  Since a backup's name doesn't stay with its
+	// contents, NumericBackups can't be used with Uploader, WriteIndex,
+	// PruneExclude, PruneExcludeFunc or PinBackup; writing returns an error
+	// wrapping ErrNumericBackups if any of those is used.
+	NumericBackups bool `json:"numericbackups" yaml:"numericbackups"`
+
+	// RemoveEmpty determines if Close removes the log file when the Logger
+	// created it and nothing has been written to it since, apart from any
+	// Banner and Header, so that programs that often run without logging
+	// anything don't leave empty log files behind.
+	RemoveEmpty bool `json:"removeempty" yaml:"removeempty"`
+
+	// AsyncRotate determines if rotation switches writes to a newly opened
+	// file straight away and leaves renaming the old file to a backup, and
+	// the new one into its place, to a goroutine, so the Write that causes
+	// the rotation isn't held up by them.  Until the renames are done, the
+	// new file has a temporary name starting with a dot.  AsyncRotate is
+	// ignored on Windows, which can't rename open files.
+	AsyncRotate bool `json:"asyncrotate" yaml:"asyncrotate"`
+
+	// SpareFile determines if the next log file is created ahead of time,
+	// under the same temporary name that AsyncRotate uses, so that rotating
+	// is just a matter of renaming files and doesn't wait for a file to be
+	// created, which can take a while on slow filesystems.  The spare file is
+	// removed by Close.
+	SpareFile bool `json:"sparefile" yaml:"sparefile"`
+
+	// OnEvent, if set, is called with notable events such as failing over to
+	// FallbackFilename.  It may be called from a background goroutine, or
+	// while the Logger holds its lock, so it must not call back into the
+	// Logger, and it may be called from more than one goroutine at once, by
+	// writers and the Logger's own goroutines for uploads, AsyncRotate,
+	// DiskPressure and Heartbeat, so it must be safe for concurrent use.
+	OnEvent func(Event) `json:"-" yaml:"-"`
+
+	// OnRotate, if set, is called with the name of the new backup and the
+	// reason each time the log file is rotated.  It is called in the same
+	// way as OnEvent, so it must be safe for concurrent use and must not call
+	// back into the Logger either.
+	OnRotate func(backup string, reason RotateReason) `json:"-" yaml:"-"`
+
+	// Metrics, if set, is told how many bytes are written and when backups
+	// are rotated, compressed and removed, and of errors, for passing on to
+	// a metrics system.  Its methods may be called concurrently.
+	Metrics Metrics `json:"-" yaml:"-"`
+
+	// Labels, if set, name the log stream, with keys such as "service",
+	// "component" or "tenant", so that a process with several Loggers can
+	// tell their signals apart.  They are added to every Event, and so to
+	// the journal and WebhookURL payloads, and given to Metrics if it is a
+	// LabeledMetrics.  They shouldn't be changed once the Logger is in use.
+	Labels map[string]string `json:"labels" yaml:"labels"`
+
+	// WriteLatency, if set, is called after each Write with how long it
+	// took, and whether the log file was rotated while it was under way,
+	// which is when writes are slowest, for building latency histograms.
+	// It is called on the writing goroutine, so it should be quick.
+	WriteLatency func(d time.Duration, rotated bool) `json:"-" yaml:"-"`
+
+	// WriteSample, if set, is called with the length of one in every
+	// SampleEvery calls to Write, before it is written, for building up the
+	// distribution of log record sizes and spotting the oversized ones
+	// without paying for a hook on every Write.  It is called on the writing
+	// goroutine, so it should be quick.
+	WriteSample func(size int) `json:"-" yaml:"-"`
+
+	// SampleEvery is how many calls to Write there are for each one passed
+	// to WriteSample.  The default is to pass every one.
+	SampleEvery int `json:"sampleevery" yaml:"sampleevery"`
+
+	// OnNearLimit, if set, is called with the size of the log file and
+	// MaxSize, in bytes, when the log file grows past NearLimitPercent of
+	// MaxSize, so that the application can report it or log less before the
+	// file is rotated.  It is called once for each log file, while the Logger
+	// holds its lock, so it must not call back into the Logger.
+	OnNearLimit func(size, max int64) `json:"-" yaml:"-"`
+
+	// NearLimitPercent is the percentage of MaxSize at which OnNearLimit is
+	// called.  It defaults to 90.
+	NearLimitPercent int `json:"nearlimitpercent" yaml:"nearlimitpercent"`
+
+	// WriteIndex determines if a JSON index of the backups, with their sizes
+	// and checksums, is kept in a file named after the log file with
+	// ".index.json" appended.  The index is rewritten atomically after each
+	// round of compression and removal.  See BackupIndex for its format.
+	WriteIndex bool `json:"writeindex" yaml:"writeindex"`
+
+	// Uploader, if set, is used to upload backups once they have been
+	// rotated and, if Compress is set, compressed.  Uploads happen in the
+	// background and failed ones are retried with backoff, up to
+	// UploadAttempts times in all, before being given up on, which is
+	// reported as an EventUpload with Err set.  Close cancels the context of
+	// an upload that is under way; backups still waiting are uploaded once
+	// the Logger is used again or, with UploadStateFile, the next time the
+	// program runs.  S3Uploader is an Uploader for S3-compatible object
+	// stores.
+	Uploader Uploader `json:"-" yaml:"-"`
+
+	// CompressSink, if set, is where Compress writes each compressed backup,
+	// instead of to a file next to the log file, so that it can go straight
+	// to object storage, say, without taking up local disk first.  It is
+	// called with the name the compressed backup would have had, without its
+	// directory, and the backup is removed once the returned writer has been
+	// closed without error.  If compressing fails, the writer is closed with
+	// CloseWithError if it has that method, and the backup is kept to be
+	// tried again.  Compressed backups then never exist locally, so the
+	// retention settings and Uploader don't see them.
+	CompressSink func(name string) (io.WriteCloser, error) `json:"-" yaml:"-"`
+
+	// UploadAttempts is how many times an upload is tried before giving up.
+	// It defaults to 3.
+	UploadAttempts int `json:"uploadattempts" yaml:"uploadattempts"`
+
+	// UploadRetryDelay is how long to wait before retrying a failed upload,
+	// doubling for each retry after that.  It defaults to one second.
+	UploadRetryDelay time.Duration `json:"uploadretrydelay" yaml:"uploadretrydelay"`
+
+	// RemoveAfterUpload determines if backups are removed once they have been
+	// uploaded successfully.
+	RemoveAfterUpload bool `json:"removeafterupload" yaml:"removeafterupload"`
+
+	// UploadStateFile, if set, is a file in which to keep track of which
+	// backups have been uploaded.  With it, every finished backup that it
+	// doesn't list is uploaded, including ones left over from before a
+	// restart, and none is uploaded twice.  Without it, only backups rotated
+	// by this Logger are uploaded.
+	UploadStateFile string `json:"uploadstatefile" yaml:"uploadstatefile"`
+
+	// UploadInterval, if set, makes uploads happen in batches this often,
+	// rather than as soon as each backup is finished.
+	UploadInterval time.Duration `json:"uploadinterval" yaml:"uploadinterval"`
+
+	// LinkDir, if set, is a directory in which a hard link to each backup is
+	// made as soon as it is rotated, for an external archiver to consume and
+	// remove at its own pace.  Compression and retention only ever remove
+	// the Logger's own name for a backup, so the archiver never sees a file
+	// disappear or change under it.  LinkDir must be on the same filesystem
+	// as the log file.  Failures are reported as EventLink events.
+	LinkDir string `json:"linkdir" yaml:"linkdir"`
+
+	// Header, if set, is called with each newly created log file before
+	// anything else is written to it, so that every file can start with the
+	// same banner or schema line.  What it writes counts toward MaxSize.  It
+	// is not called when appending to an existing file.
+	Header func(w io.Writer) error `json:"-" yaml:"-"`
+
+	// Banner, if set, is a line written at the top of each newly created log
+	// file, before anything Header writes, for when a fixed line is all that
+	// is wanted.  In it, {hostname} and {pid} are replaced by the host's name
+	// and the process ID, and any other part between braces is a time
+	// layout, as for time.Format, filled in with the current time, in UTC
+	// unless LocalTime is set, as in
+	// "# started {2006-01-02T15:04:05Z07:00} on {hostname} by {pid}".  A
+	// newline is added if it doesn't end with one.
+	Banner string `json:"banner" yaml:"banner"`
+
+	// Stat, if set, is used instead of os.Stat to look up the log file and
+	// its backups, for sandboxed environments and tests.
+	Stat func(name string) (os.FileInfo, error) `json:"-" yaml:"-"`
+
+	// Chown, if set, is used instead of os.Chown to give new log files and
+	// compressed backups the owner of the file they replace.  Only used on
+	// Linux.
+	Chown func(name string, uid, gid int) error `json:"-" yaml:"-"`
+
+	// Rename, if set, is used instead of os.Rename to move the log file aside
+	// when it is rotated.  Without it, an existing file with the backup's name
+	// is never overwritten, and rotation fails instead, but Rename must check
+	// for that itself.  Where that can't be done in one step, as on platforms
+	// other than Linux, an EventRenameUnchecked is reported.
+	Rename func(oldpath, newpath string) error `json:"-" yaml:"-"`
+
+	// Journal determines if every rotation, compression and removal of a
+	// backup is recorded in a journal file named after the log file with
+	// ".rotations" appended, one JSON event per line, as an audit trail of
+	// what the Logger has done to the directory.  See Event.MarshalJSON for
+	// the format.  Failures to write to the journal are ignored.
+	Journal bool `json:"journal" yaml:"journal"`
+
+	// WebhookURL, if set, is a URL that every event is POSTed to as JSON, in
+	// the background, so that other systems can react to rotations and the
+	// like.  See Event.MarshalJSON for the format.  Events are dropped if the
+	// URL can't keep up.  Close waits up to WebhookDrainTimeout for the
+	// events already queued to be sent, and drops the rest.
+	WebhookURL string `json:"webhookurl" yaml:"webhookurl"`
+
+	// WebhookDrainTimeout is how long Close waits for the events queued for
+	// WebhookURL to be sent.  Other uses of the Logger aren't held up
+	// meanwhile.  It defaults to ten seconds.
+	WebhookDrainTimeout time.Duration `json:"webhookdraintimeout" yaml:"webhookdraintimeout"`
+
+	// PurgeOnNoSpace determines if, when a write or rotation fails because the
+	// disk is full, the oldest backups are removed one at a time and the
+	// operation retried until it succeeds or no removable backups remain.  The
+	// default is to return the error.
+	PurgeOnNoSpace bool `json:"purgeonnospace" yaml:"purgeonnospace"`
+
+	// PurgeKeep is the number of most recent backups that PurgeOnNoSpace will
+	// never remove.
+	PurgeKeep int `json:"purgekeep" yaml:"purgekeep"`
+
+	// DiskPressure, if set, has the disk checked in the background, and
+	// cleanup stepped up while it is too full.  See DiskPressure.
+	DiskPressure *DiskPressure `json:"diskpressure" yaml:"diskpressure"`
+
 	size int64
 	file *os.File
 	mu   sync.Mutex
 
+	// gz is the gzip stream written to file, if CompressActive is set, and
+	// flushTimer, if set, is waiting to flush it.
+	gz         *gzip.Writer
+	flushTimer *time.Timer
+
+	// batchMu guards batch, the batch of writes that is gathering, if any.
+	batchMu sync.Mutex
+	batch   *writeBatch
+
+	// handoff, if set, is closed once the renames for a rotation done by
+	// rotateHandoff are finished.
+	handoff chan struct{}
+
+	// stranded, if set, records the renames that a rotateHandoff couldn't
+	// make, leaving the log file that is being written under its temporary
+	// name, so that they are tried again.  It is set before handoff is
+	// closed.
+	stranded *strandedHandoff
+
+	// renameUnchecked is set (atomically) once EventRenameUnchecked has been
+	// reported.
+	renameUnchecked int32
+
+	// spareCh, if set, delivers the spare file for SpareFile, or nil if it
+	// couldn't be made.
+	spareCh chan *os.File
+
+	// unused is set while the log file is one that openNew created and
+	// nothing has been written to it.
+	unused bool
+
+	// closed is set by Close, and cleared if ReopenOnWrite reopens the log
+	// file.
+	closed bool
+
+	// usingFallback is set (atomically, since the mill reads it) while writes
+	// are going to FallbackFilename.
+	usingFallback int32
+	lastProbe     time.Time
+
+	// current is the name that the log file was opened under, for
+	// TimedFilename.
+	current string
+
+	// passthrough is set while the log file is one that can't be rotated.
+	passthrough bool
+
+	// onStderr is set while writes are going to stderr.
+	onStderr bool
+
+	// membuf holds log data that couldn't be written, if MemoryBuffer is set.
+	// buffering is set while writes are going to it, and replaying while it's
+	// being written out.
+	membuf    *memBuffer
+	buffering bool
+	replaying bool
+
+	// lastReconcile is when the log file was last checked against the disk.
+	lastReconcile time.Time
+
+	// writes is how many calls to Write have gone into the log file, for
+	// MaxWrites.
+	writes int
+
+	// reason is why the rotation under way is happening.
+	reason RotateReason
+
+	// rotateTime, if set, is the time to name the backup made by the
+	// rotation under way after, so that a Group's backups match.
+	rotateTime time.Time
+
+	// pressureStop is closed to stop the DiskPressure check, which closes
+	// pressureDone once it has, and pressureStep is the step it last took.
+	pressureStop chan struct{}
+	pressureDone chan struct{}
+	pressureStep int
+
+	// heartbeatStop is closed to stop the Heartbeat goroutine, and
+	// lastWrite is when something was last written to the log file.
+	// beating is set while a heartbeat is being written, so that it isn't
+	// counted for MaxWrites.
+	heartbeatStop chan struct{}
+	lastWrite     time.Time
+	beating       bool
+
+	// openedAt is when the log file was opened, with the monotonic clock
+	// reading that time.Now gives, for RotateInterval.
+	openedAt time.Time
+
+	// dailyNext is when RotateDaily next rotates, if it has been worked out
+	// for the open log file, and dailyDay is the day, as midnight UTC, that
+	// it's for.  It is worked out from dailyFrom, the time the file was
+	// opened or last written to before that.  dailyDone is the last day that
+	// had its rotation.
+	dailyNext time.Time
+	dailyFrom time.Time
+	dailyDay  time.Time
+	dailyDone time.Time
+
+	// stalled is set (atomically) while a write that timed out is still
+	// running in the background.
+	stalled int32
+
+	// followers are the readers returned by Follow that are still open.
+	followers map[*follower]struct{}
+
 	millCh    chan bool
+	millStop  chan struct{}
+	millDone  chan struct{}
 	startMill sync.Once
+
+	// millPending counts (atomically) the rounds of cleanup waiting in
+	// millCh.
+	millPending int32
+
+	// rotations counts (atomically) the rotations there have been, so that
+	// WriteLatency can tell which writes were held up by one.
+	rotations int64
+
+	// sampled counts (atomically) the calls to Write, for WriteSample.
+	sampled uint64
+
+	// millMu makes sure only one round of cleanup runs at a time.
+	millMu sync.Mutex
+
+	// paused is set (atomically) while maintenance is paused.
+	paused int32
+
+	// backupBytes is roughly the total size of the backups, as of the last
+	// cleanup plus rotations since.  It is accessed atomically.
+	backupBytes int64
+
+	// uploadMu guards toUpload, the backups rotated since they were last
+	// handed off for uploading.
+	uploadMu    sync.Mutex
+	toUpload    []string
+	uploadCh    chan string
+	startUpload sync.Once
+
+	// uploadStop is closed to stop the upload goroutine, which closes
+	// uploadDone once it has.  uploadCtx is what uploads are done with, and
+	// uploadCancel cancels it when the Logger is closed; they are guarded by
+	// uploadMu.
+	uploadStop   chan struct{}
+	uploadDone   chan struct{}
+	uploadCtx    context.Context
+	uploadCancel context.CancelFunc
+
+	// uploaded and uploading are the names of the backups that have been
+	// uploaded and are being uploaded, when there is an UploadStateFile.
+	// They are also guarded by uploadMu.
+	uploaded  map[string]bool
+	uploading map[string]bool
+
+	// journalMu makes sure events are appended to the journal whole.
+	journalMu sync.Mutex
+
+	// webhookMu guards webhookCh, on which events are sent to the goroutine
+	// that POSTs them to the WebhookURL, webhookDone, which that goroutine
+	// closes when it returns, webhookCancel, which gives up on the events it
+	// has yet to send, and webhookStopped, which is set once Close has
+	// stopped it.
+	webhookMu      sync.Mutex
+	webhookCh      chan Event
+	webhookDone    chan struct{}
+	webhookCancel  context.CancelFunc
+	webhookStopped bool
+
+	// linkMu guards linkFrom and linkTo, the log file name that is a
+	// symlink, for FollowSymlink, and the file it was last found to point
+	// to.
+	linkMu   sync.Mutex
+	linkFrom string
+	linkTo   string
+
+	// metrics is Metrics, or what it returned from WithLabels.
+	metrics     Metrics
+	metricsOnce sync.Once
+
+	// statsMu guards stats.
+	statsMu sync.Mutex
+	stats   Stats
+
+	// errMu guards millErr, the error from the most recent mill run, which
+	// is written by the mill goroutine, and unreported, the errors from mill
+	// runs that neither Write nor Close has returned yet, of which there were
+	// dropped more than fit.
+	errMu      sync.Mutex
+	millErr    error
+	unreported []error
+	dropped    int
 }
 
 var (
 	// currentTime exists so it can be mocked out by tests.
 	currentTime = time.Now
 
-	// os_Stat exists so it can be mocked out by tests.
-	osStat = os.Stat
-
 	// megabyte is the conversion factor between MaxSize and bytes.  It is a
 	// variable so tests can mock it out and not need to write megabytes of data
 	// to disk.
 	megabyte = 1024 * 1024
+
+	// failbackInterval is how often a Logger that has failed over retries its
+	// primary Filename.
+	failbackInterval = time.Minute
 )
 
 // Write implements io.Writer.  If a write would cause the log file to be larger
 // than MaxSize, the file is closed, renamed to include a timestamp of the
 // current time, and a new log file is created using the original log file name.
 // If the length of the write is greater than MaxSize, an error is returned.
+// If WriteTimeout is set and the write takes longer than that, ErrWriteTimeout
+// is returned.  If BatchWindow is set, small writes are gathered up and made
+// together.
 func (l *Logger) Write(p []byte) (n int, err error) {
+	return l.WriteContext(context.Background(), p)
+}
+
+// writeDirect writes p without batching it.
+func (l *Logger) writeDirect(p []byte) (n int, err error) {
+	if l.WriteTimeout > 0 {
+		return l.writeAsync(context.Background(), p)
+	}
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.write(p)
+}
 
+// write does the work of Write.  It must be called with l.mu held.
+func (l *Logger) write(p []byte) (n int, err error) {
+	if err := l.checkClosed(); err != nil {
+		return 0, err
+	}
 	writeLen := int64(len(p))
-	if writeLen > l.max() {
+	if writeLen > l.max() && !l.passingThrough() {
 		return 0, fmt.Errorf(
 			"write length %d exceeds maximum file size %d", writeLen, l.max(),
 		)
 	}
 
+	l.replay()
+
 	if l.file == nil {
 		if err = l.openExistingOrNew(len(p)); err != nil {
-			return 0, err
+			if err = l.failover(err, len(p)); err != nil {
+				return l.bufferWrite(p, 0, err)
+			}
+		}
+	}
+
+	l.failback()
+	if err = l.switchTimed(len(p)); err != nil {
+		if err = l.failover(err, len(p)); err != nil {
+			return l.bufferWrite(p, 0, err)
 		}
 	}
+	if err = l.reconcile(len(p)); err != nil {
+		return 0, err
+	}
 
-	if l.size+writeLen > l.max() {
-		if err := l.rotate(); err != nil {
-			return 0, err
+	writes := l.writesDue()
+	reason := RotateSize
+	if l.size+writeLen <= l.max() {
+		reason = RotateSchedule
+		if writes {
+			reason = RotateWrites
+		}
+	}
+	daily, err := l.dailyDue()
+	if err != nil {
+		return 0, err
+	}
+	if daily {
+		l.dailyDone = l.dailyDay
+	}
+	if (l.size+writeLen > l.max() || l.rotateDue() || daily || writes) && !l.passthrough {
+		err := l.rotate(reason)
+		for err != nil && l.purgeForSpace(err) {
+			err = l.rotate(reason)
+		}
+		if err != nil {
+			if err = l.failover(err, len(p)); err != nil {
+				return l.bufferWrite(p, 0, err)
+			}
 		}
 	}
 
-	n, err = l.file.Write(p)
-	l.size += int64(n)
+	prev := l.size
+	n, err = l.writeFile(p)
+	l.checkTotalSize(prev)
+	for err != nil && l.purgeForSpace(err) {
+		n, err = l.writeRest(p, n)
+	}
+	if err != nil && l.failover(err, len(p)-n) == nil {
+		n, err = l.writeRest(p, n)
+	}
+	if n > 0 {
+		l.unused = false
+		l.lastWrite = currentTime()
+		if !l.replaying && !l.beating {
+			l.writes++
+		}
+	}
+	l.checkNearLimit(prev)
+	l.wakeFollowers()
 
+	n, err = l.bufferWrite(p, n, err)
+	if err == nil && l.StrictErrors {
+		err = l.takeMillErr()
+	}
 	return n, err
 }
 
-// Close implements io.Closer, and closes the current logfile.
+// writeRest retries a write of p to the current file after n bytes of it
+// were already written, returning the new total written.
+func (l *Logger) writeRest(p []byte, n int) (int, error) {
+	m, err := l.writeFile(p[n:])
+	return n + m, err
+}
+
+// Close implements io.Closer, and closes the current logfile.  It then waits
+// for any background compression and removal of old log files to finish, and
+// returns, wrapped, any errors from them that haven't already been returned
+// by Write, so that a short-lived program has a chance to notice that cleanup
+// failed.  Log data held in memory because of MemoryBuffer is written to
+// the log file first, if it can be.  Calling Close again does nothing.  A
+// Logger that is used after Close returns ErrClosed, unless ReopenOnWrite is
+// set.
 func (l *Logger) Close() error {
 	l.mu.Lock()
-	defer l.mu.Unlock()
-	return l.close()
+	l.replay()
+	l.dropSpare()
+	var err error
+	if l.RemoveEmpty && l.unused && l.file != nil {
+		name := l.file.Name()
+		if err = l.close(); err == nil {
+			err = os.Remove(name)
+		}
+	} else {
+		err = l.close()
+	}
+	l.stopMill()
+	l.millMu.Lock()
+	l.stopUploads()
+	l.millMu.Unlock()
+	l.stopPressure()
+	l.stopHeartbeat()
+	drainWebhook := l.stopWebhook()
+	l.closed = true
+	err = errors.Join(err, l.takeMillErr())
+	l.mu.Unlock()
+
+	drainWebhook()
+	return err
+}
+
+// checkClosed returns ErrClosed if Close has been called, unless
+// ReopenOnWrite is set, in which case it notes that the Logger is in use
+// again.  It must be
+// called with l.mu held.
+func (l *Logger) checkClosed() error {
+	if !l.closed {
+		return nil
+	}
+	if !l.ReopenOnWrite {
+		return ErrClosed
+	}
+	l.closed = false
+	l.restartWebhook()
+	return nil
 }
 
 // close closes the file if it is open.
 func (l *Logger) close() error {
+	l.waitHandoff()
 	if l.file == nil {
 		return nil
 	}
-	err := l.file.Close()
+	err := l.retryHandoff()
+	if gerr := l.stopGzip(); err == nil {
+		err = gerr
+	}
+	if cerr := l.closeFile(l.file); err == nil {
+		err = cerr
+	}
 	l.file = nil
+	l.passthrough = false
+	return err
+}
+
+// closeFile closes the log file f, first clearing the append-only attribute
+// if AppendOnly is set.
+func (l *Logger) closeFile(f *os.File) error {
+	var err error
+	if l.AppendOnly && !l.passthrough {
+		err = setAppendOnly(f, false)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
 	return err
 }
 
@@ -186,13 +1058,35 @@ func (l *Logger) close() error {
 func (l *Logger) Rotate() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
-	return l.rotate()
+	if err := l.checkClosed(); err != nil {
+		return err
+	}
+	return l.rotate(RotateManual)
 }
 
 // rotate closes the current file, moves it aside with a timestamp in the name,
 // (if it exists), opens a new file with the original filename, and then runs
-// post-rotation processing and removal.
-func (l *Logger) rotate() error {
+// post-rotation processing and removal.  The reason is reported with the
+// rotation.
+func (l *Logger) rotate(reason RotateReason) error {
+	l.reason = reason
+	defer func() { l.reason = 0 }()
+	atomic.AddInt64(&l.rotations, 1)
+	if l.passingThrough() {
+		if l.file != nil {
+			return nil
+		}
+		return l.openPassthrough(l.filename())
+	}
+	l.waitHandoff()
+	if err := l.retryHandoff(); err != nil {
+		return err
+	}
+	if l.canHandoff() {
+		if ok, err := l.rotateHandoff(); ok {
+			return err
+		}
+	}
 	if err := l.close(); err != nil {
 		return err
 	}
@@ -208,48 +1102,180 @@ func (l *Logger) rotate() error {
 func (l *Logger) openNew() error {
 	err := os.MkdirAll(l.dir(), 0755)
 	if err != nil {
-		return fmt.Errorf("can't make directories for new logfile: %s", err)
+		return failed(ErrOpenFailed, fmt.Errorf("can't make directories for new logfile: %w", err))
 	}
 
 	name := l.filename()
+	l.current = name
+	if err := l.checkPath(name); err != nil {
+		return failed(ErrOpenFailed, err)
+	}
 	mode := os.FileMode(0600)
-	info, err := osStat(name)
-	if err == nil {
+	info, err := l.stat(name)
+	if err != nil {
+		info = nil
+	} else {
 		// Copy the mode off the old logfile.
 		mode = info.Mode()
+	}
+	var unnamed *os.File
+	if l.AtomicCreate {
+		// fall back to creating it the usual way if this fails.
+		unnamed, _ = createUnnamed(name, mode)
+	}
+	if info != nil {
+		// a file left append-only by a crash can't be renamed.
+		if l.AppendOnly {
+			if f, err := os.Open(name); err == nil {
+				_ = setAppendOnly(f, false)
+				f.Close()
+			}
+		}
 		// move the existing file
-		newname := backupName(name, l.LocalTime)
-		if err := os.Rename(name, newname); err != nil {
-			return fmt.Errorf("can't rename log file: %s", err)
+		newname := l.backupName(name)
+		if err := l.beginRotation(name, newname, ""); err != nil {
+			if unnamed != nil {
+				unnamed.Close()
+			}
+			return failed(ErrRotateFailed, fmt.Errorf("can't record rotation: %w", err))
+		}
+		defer l.endRotation(name)
+		if l.NumericBackups {
+			if err := l.shiftBackups(name); err != nil {
+				if unnamed != nil {
+					unnamed.Close()
+				}
+				return failed(ErrRotateFailed, err)
+			}
+		}
+		if unnamed != nil {
+			unnamed, err = l.swapIn(unnamed, name, newname)
+		} else {
+			err = l.renameBackup(name, newname)
+		}
+		if err != nil {
+			if unnamed != nil {
+				unnamed.Close()
+			}
+			return failed(ErrRotateFailed, fmt.Errorf("can't rename log file: %w", err))
 		}
+		l.queueUpload(newname)
+		l.linkBackup(newname)
+		atomic.AddInt64(&l.backupBytes, info.Size())
+		l.emitRotate(Event{Type: EventRotate, Filename: newname, Source: name, Size: info.Size(), Reason: l.rotateReason()})
 
 		// this is a no-op anywhere but linux
-		if err := chown(name, info); err != nil {
+		if err := l.chown(name, info); err != nil {
+			if unnamed != nil {
+				unnamed.Close()
+			}
 			return err
 		}
+		if !l.compresses(info.Size()) {
+			if err := l.makeReadOnly(newname); err != nil {
+				return err
+			}
+		}
 	}
 
-	// we use truncate here because this should only get called when we've moved
-	// the file ourselves. if someone else creates the file in the meantime,
-	// just wipe out the contents.
-	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
-	if err != nil {
-		return fmt.Errorf("can't open new logfile: %s", err)
+	if unnamed != nil && info == nil {
+		if err := linkUnnamed(unnamed, name); err != nil {
+			unnamed.Close()
+			unnamed = nil
+		}
+	}
+	f := unnamed
+	if f == nil {
+		f = l.takeSpare(name)
+	}
+	if f != nil && f != unnamed && l.rename(f.Name(), name) != nil {
+		f.Close()
+		os.Remove(f.Name())
+		f = nil
+	}
+	if f == nil {
+		// we use truncate here because this should only get called when we've
+		// moved the file ourselves. if someone else creates the file in the
+		// meantime, just wipe out the contents.
+		f, err = os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC|os.O_APPEND|l.noFollow(), mode)
+		if err != nil {
+			return failed(ErrOpenFailed, fmt.Errorf("can't open new logfile: %w", err))
+		}
+	}
+	if err := l.makeAppendOnly(f); err != nil {
+		return err
 	}
 	l.file = f
 	l.size = 0
+	l.unused = true
+	l.opened()
+	l.saveSchedule()
+	l.startGzip()
+	l.followFile(name)
+	l.prepareSpare()
+	return l.writeHeader()
+}
+
+// makeAppendOnly gives the newly opened log file f the append-only attribute
+// if AppendOnly is set, closing f if that fails.
+func (l *Logger) makeAppendOnly(f *os.File) error {
+	if !l.AppendOnly {
+		return nil
+	}
+	if err := setAppendOnly(f, true); err != nil {
+		f.Close()
+		return fmt.Errorf("can't make log file append-only: %w", err)
+	}
 	return nil
 }
 
+// swapIn moves the log file name to newname and puts f, made by
+// createUnnamed, in its place.  The old file is linked to newname rather than
+// renamed, so that name is never missing, unless the filesystem doesn't
+// support links.  If f can't be given the name, the link is undone, f is
+// closed, and swapIn returns a nil file with the old file renamed to newname,
+// so that a new file can be created the usual way.
+func (l *Logger) swapIn(f *os.File, name, newname string) (*os.File, error) {
+	linked := true
+	if err := os.Link(name, newname); err != nil {
+		if os.IsExist(err) {
+			return f, err
+		}
+		if err := l.renameBackup(name, newname); err != nil {
+			return f, err
+		}
+		linked = false
+	}
+	if err := linkUnnamed(f, name); err == nil {
+		return f, nil
+	}
+	f.Close()
+	if linked {
+		// both names are still the old file, so take the backup's away again
+		// and move the old file there instead.
+		if err := os.Remove(newname); err != nil {
+			return nil, err
+		}
+		if err := l.renameBackup(name, newname); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 // backupName creates a new filename from the given name, inserting a timestamp
 // between the filename and the extension, using the local time if requested
 // (otherwise UTC).
 func backupName(name string, local bool) string {
+	return backupNameAt(name, currentTime(), local)
+}
+
+// backupNameAt is backupName with the timestamp for time t.
+func backupNameAt(name string, t time.Time, local bool) string {
 	dir := filepath.Dir(name)
 	filename := filepath.Base(name)
 	ext := filepath.Ext(filename)
 	prefix := filename[:len(filename)-len(ext)]
-	t := currentTime()
 	if !local {
 		t = t.UTC()
 	}
@@ -262,36 +1288,75 @@ func backupName(name string, local bool) string {
 // would not put it over MaxSize.  If there is no such file or the write would
 // put it over the MaxSize, a new file is created.
 func (l *Logger) openExistingOrNew(writeLen int) error {
+	if err := l.filenameErr(); err != nil {
+		return err
+	}
+	if err := l.dailyErr(); err != nil {
+		return err
+	}
+	if err := l.numericErr(); err != nil {
+		return err
+	}
+	l.forgetLink()
+	filename := l.filename()
+	l.current = filename
+	if l.isPassthrough(filename) {
+		return l.openPassthrough(filename)
+	}
+
 	l.mill()
+	l.startPressure()
+	l.startHeartbeat()
 
-	filename := l.filename()
-	info, err := osStat(filename)
+	if err := l.checkPath(filename); err != nil {
+		return failed(ErrOpenFailed, err)
+	}
+	l.recoverRotation(filename)
+	info, err := l.stat(filename)
 	if os.IsNotExist(err) {
 		return l.openNew()
 	}
 	if err != nil {
-		return fmt.Errorf("error getting log file info: %s", err)
+		return failed(ErrOpenFailed, fmt.Errorf("error getting log file info: %s", err))
 	}
 
 	if info.Size()+int64(writeLen) >= l.max() {
-		return l.rotate()
+		return l.rotate(RotateStartup)
 	}
 
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0644)
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY|l.noFollow(), 0644)
 	if err != nil {
 		// if we fail to open the old log file for some reason, just ignore
 		// it and open a new log file.
 		return l.openNew()
 	}
+	if err := l.makeAppendOnly(file); err != nil {
+		return err
+	}
 	l.file = file
+	l.openedExisting(info)
+	l.restoreSchedule()
 	l.size = info.Size()
+	l.unused = false
+	l.startGzip()
+	l.followFile(filename)
+	l.prepareSpare()
 	return nil
 }
 
-// filename generates the name of the logfile from the current time.
+// filename returns the name of the logfile currently in use, which is the
+// fallback file while the Logger has failed over.
 func (l *Logger) filename() string {
+	if l.fallbackActive() {
+		return l.decorate(l.FallbackFilename)
+	}
+	return l.primaryFilename()
+}
+
+// primaryFilename generates the name of the logfile from the configuration.
+func (l *Logger) primaryFilename() string {
 	if l.Filename != "" {
-		return l.Filename
+		return l.decorate(l.Filename)
 	}
 	name := filepath.Base(os.Args[0]) + "-lumberjack.log"
 	return filepath.Join(os.TempDir(), name)
@@ -300,16 +1365,28 @@ func (l *Logger) filename() string {
 // millRunOnce performs compression and removal of stale log files.
 // Log files are compressed if enabled via configuration and old log
 // files are removed, keeping at most l.MaxBackups files, as long as
-// none of them are older than MaxAge.
+// none of them are older than MaxAge.  If anything fails, the returned
+// error joins together the errors for every file affected.
 func (l *Logger) millRunOnce() error {
-	if l.MaxBackups == 0 && l.MaxAge == 0 && !l.Compress {
+	defer l.startUploads()
+	if !l.millEnabled() {
 		return nil
 	}
+	var ms MillStats
+	start := time.Now()
+	defer func() {
+		ms.Duration = time.Since(start)
+		l.recordMill(ms)
+	}()
 
-	files, err := l.oldLogFiles()
+	// FallbackFilename can switch the directory while the mill is running,
+	// so the backups are listed and handled in the one directory throughout.
+	dir := l.dir()
+	files, err := l.oldLogFilesIn(dir)
 	if err != nil {
 		return err
 	}
+	files = l.prunable(files)
 
 	var compress, remove []logInfo
 
@@ -319,10 +1396,7 @@ func (l *Logger) millRunOnce() error {
 		for _, f := range files {
 			// Only count the uncompressed log file or the
 			// compressed log file, not both.
-			fn := f.Name()
-			if strings.HasSuffix(fn, compressSuffix) {
-				fn = fn[:len(fn)-len(compressSuffix)]
-			}
+			fn := trimCompressExt(f.Name())
 			preserved[fn] = true
 
 			if len(preserved) > l.MaxBackups {
@@ -334,8 +1408,10 @@ func (l *Logger) millRunOnce() error {
 		files = remaining
 	}
 	if l.MaxAge > 0 {
-		diff := time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
-		cutoff := currentTime().Add(-1 * diff)
+		cutoff, err := l.ageCutoff()
+		if err != nil {
+			return err
+		}
 
 		var remaining []logInfo
 		for _, f := range files {
@@ -347,60 +1423,296 @@ func (l *Logger) millRunOnce() error {
 		}
 		files = remaining
 	}
+	if l.MaxTotalSize > 0 {
+		var gone []logInfo
+		files, gone = l.budgetFilter(files)
+		remove = append(remove, gone...)
+	}
+	if l.MaxBackupsPerDay > 0 {
+		var gone []logInfo
+		files, gone, err = l.perDayFilter(files)
+		if err != nil {
+			return err
+		}
+		remove = append(remove, gone...)
+	}
+	if l.GFS != nil {
+		var gone []logInfo
+		files, gone, err = l.GFS.filter(files, l)
+		if err != nil {
+			return err
+		}
+		remove = append(remove, gone...)
+	}
+	if l.Thin != nil {
+		var gone []logInfo
+		files, gone, err = l.Thin.filter(files, l)
+		if err != nil {
+			return err
+		}
+		remove = append(remove, gone...)
+	}
 
+	var c *codec
 	if l.Compress {
+		if c, err = l.codec(); err != nil {
+			return err
+		}
 		for _, f := range files {
-			if !strings.HasSuffix(f.Name(), compressSuffix) {
+			if !isCompressed(f.Name()) && l.compresses(f.Size()) {
 				compress = append(compress, f)
 			}
 		}
 	}
 
-	for _, f := range remove {
-		errRemove := os.Remove(filepath.Join(l.dir(), f.Name()))
-		if err == nil && errRemove != nil {
-			err = errRemove
-		}
+	// keep going after errors, so one bad file doesn't hold up the rest, and
+	// report them all.  Removals don't depend on each other or on the
+	// compressions, so unless ManualCleanup is set they go on alongside them.
+	// They're reported once the compressions are done, and in order, so
+	// that this round's events don't reach OnEvent interleaved.
+	removed := make(chan []Event, 1)
+	if l.ManualCleanup {
+		removed <- l.removeBackups(dir, remove)
+	} else {
+		go func() {
+			removed <- l.removeBackups(dir, remove)
+		}()
 	}
+	var compressErrs []error
 	for _, f := range compress {
-		fn := filepath.Join(l.dir(), f.Name())
-		errCompress := compressLogFile(fn, fn+compressSuffix)
-		if err == nil && errCompress != nil {
-			err = errCompress
+		if errCompress := l.compressBackup(dir, f, c); errCompress != nil {
+			compressErrs = append(compressErrs, errCompress)
+		} else {
+			ms.Compressed++
+		}
+	}
+	var errs []error
+	for _, e := range <-removed {
+		l.emit(e)
+		if e.Err != nil {
+			errs = append(errs, e.Err)
+		}
+	}
+	ms.Removed = len(remove) - len(errs)
+	errs = append(errs, compressErrs...)
+	if l.WriteIndex {
+		if errIndex := l.updateIndex(); errIndex != nil {
+			errs = append(errs, errIndex)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// millRemovers is how many backups the mill removes at once.
+const millRemovers = 8
+
+// removeBackups removes files from dir, several at a time unless
+// ManualCleanup is set, and returns an EventRemove event for each of them, in
+// order, for the caller to report.
+func (l *Logger) removeBackups(dir string, files []logInfo) []Event {
+	events := make([]Event, len(files))
+	remove := func(i int, f logInfo) {
+		fn := filepath.Join(dir, f.Name())
+		err := failed(ErrRemoveFailed, removeFile(fn))
+		events[i] = Event{Type: EventRemove, Filename: fn, Size: f.Size(), Err: err}
+	}
+	if l.ManualCleanup {
+		for i, f := range files {
+			remove(i, f)
 		}
+		return events
+	}
+
+	sem := make(chan struct{}, millRemovers)
+	var wg sync.WaitGroup
+	for i, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, f logInfo) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			remove(i, f)
+		}(i, f)
 	}
+	wg.Wait()
+	return events
+}
 
+// compressBackup compresses the backup f in dir with c, reporting it with an
+// EventCompress event.
+func (l *Logger) compressBackup(dir string, f logInfo, c *codec) error {
+	fn := filepath.Join(dir, f.Name())
+	start := time.Now()
+	dst := fn + c.ext
+	var size int64
+	var err error
+	if l.CompressSink != nil {
+		dst = f.Name() + c.ext
+		size, err = l.compressToSink(fn, dst, c)
+	} else {
+		err = l.compressLogFile(fn, dst, c, l.CompressKeepName)
+		if err == nil {
+			err = l.makeReadOnly(dst)
+		}
+		if info, serr := l.stat(dst); serr == nil {
+			size = info.Size()
+		}
+	}
+	err = failed(ErrCompressFailed, err)
+	e := Event{
+		Type:     EventCompress,
+		Filename: dst,
+		Source:   fn,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if err == nil {
+		e.Size = size
+		l.recordCompression(CompressionStats{
+			Filename:   e.Filename,
+			InputSize:  f.Size(),
+			OutputSize: e.Size,
+			Duration:   e.Duration,
+		})
+	}
+	l.emit(e)
 	return err
 }
 
+// millEnabled reports whether the configuration calls for the mill to do
+// anything to the backups.
+func (l *Logger) millEnabled() bool {
+	return l.MaxBackups != 0 || l.MaxBackupsPerDay != 0 || l.MaxAge != 0 || l.Compress || l.WriteIndex ||
+		l.GFS != nil || l.Thin != nil || l.MaxTotalSize != 0
+}
+
 // millRun runs in a goroutine to manage post-rotation compression and removal
 // of old log files.
-func (l *Logger) millRun() {
-	for range l.millCh {
-		// what am I going to do, log this?
-		_ = l.millRunOnce()
+func (l *Logger) millRun(ch chan bool, stop, done chan struct{}) {
+	defer close(done)
+	for range ch {
+		atomic.AddInt32(&l.millPending, -1)
+		if l.waitForWindow(stop) {
+			_ = l.CleanNow()
+		}
+	}
+}
+
+// CleanNow compresses and removes old log files according to the
+// configuration, as is normally done in the background after each rotation,
+// and waits for it to finish.  It returns the same error that LastError will
+// report afterwards.  This is how cleanup happens when ManualCleanup is set.
+// It does nothing while maintenance is paused.
+func (l *Logger) CleanNow() error {
+	l.millMu.Lock()
+	if l.maintenancePaused() {
+		l.millMu.Unlock()
+		return nil
+	}
+	err := l.millRunOnce()
+	l.millMu.Unlock()
+	if l.Budget != nil {
+		err = errors.Join(err, l.Budget.enforce(l))
 	}
+	l.setMillErr(err)
+	return err
+}
+
+// LastError returns the error from the most recent background compression and
+// removal of old log files, or nil if that succeeded or hasn't run yet.  A
+// later successful run clears the error.
+func (l *Logger) LastError() error {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	return l.millErr
+}
+
+// maxUnreported is how many mill errors are kept until Write or Close
+// returns them.
+const maxUnreported = 10
+
+// setMillErr records the result of the most recent mill run.
+func (l *Logger) setMillErr(err error) {
+	l.errMu.Lock()
+	l.millErr = err
+	if err != nil {
+		if len(l.unreported) < maxUnreported {
+			l.unreported = append(l.unreported, err)
+		} else {
+			l.dropped++
+		}
+	}
+	l.errMu.Unlock()
+}
+
+// takeMillErr returns the errors from the mill runs since it was last called,
+// joined and wrapped, or nil if there were none.
+func (l *Logger) takeMillErr() error {
+	l.errMu.Lock()
+	defer l.errMu.Unlock()
+	if len(l.unreported) == 0 {
+		return nil
+	}
+	err := errors.Join(l.unreported...)
+	if l.dropped > 0 {
+		err = fmt.Errorf("%w\n(and %d more)", err, l.dropped)
+	}
+	l.unreported, l.dropped = nil, 0
+	return fmt.Errorf("background cleanup of old log files failed: %w", err)
 }
 
 // mill performs post-rotation compression and removal of stale log files,
 // starting the mill goroutine if necessary.
 func (l *Logger) mill() {
+	if l.ManualCleanup {
+		return
+	}
 	l.startMill.Do(func() {
 		l.millCh = make(chan bool, 1)
-		go l.millRun()
+		l.millStop = make(chan struct{})
+		l.millDone = make(chan struct{})
+		go l.millRun(l.millCh, l.millStop, l.millDone)
 	})
 	select {
 	case l.millCh <- true:
+		atomic.AddInt32(&l.millPending, 1)
+		l.recordMillTrigger(false)
 	default:
+		l.recordMillTrigger(true)
+	}
+}
+
+// stopMill stops the mill goroutine, if it is running, after it has finished
+// any cleanup that is waiting, unless that is waiting for the
+// MaintenanceWindow.  A later rotation starts it again.  It must be
+// called with the lock held and no rotateHandoff under way, since nothing
+// else may call mill meanwhile.
+func (l *Logger) stopMill() {
+	if l.millCh == nil {
+		return
 	}
+	close(l.millStop)
+	close(l.millCh)
+	<-l.millDone
+	l.millCh, l.millStop, l.millDone = nil, nil, nil
+	l.startMill = sync.Once{}
 }
 
 // oldLogFiles returns the list of backup log files stored in the same
 // directory as the current log file, sorted by ModTime
 func (l *Logger) oldLogFiles() ([]logInfo, error) {
-	files, err := ioutil.ReadDir(l.dir())
+	return l.oldLogFilesIn(l.dir())
+}
+
+// oldLogFilesIn returns the list of backup log files stored in dir, sorted by
+// ModTime.
+func (l *Logger) oldLogFilesIn(dir string) ([]logInfo, error) {
+	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("can't read log file directory: %s", err)
+		return nil, fmt.Errorf("can't read log file directory: %w", err)
 	}
 	logFiles := []logInfo{}
 
@@ -414,14 +1726,26 @@ func (l *Logger) oldLogFiles() ([]logInfo, error) {
 			logFiles = append(logFiles, logInfo{t, f})
 			continue
 		}
-		if t, err := l.timeFromName(f.Name(), prefix, ext+compressSuffix); err == nil {
-			logFiles = append(logFiles, logInfo{t, f})
-			continue
+		if c := codecFor(f.Name()); c != nil {
+			if t, err := l.timeFromName(f.Name(), prefix, ext+c.ext); err == nil {
+				logFiles = append(logFiles, logInfo{t, f})
+				continue
+			}
+		}
+		if l.TimedFilename {
+			if t, ok := l.timedBackupTime(f.Name()); ok {
+				logFiles = append(logFiles, logInfo{t, f})
+				continue
+			}
 		}
 		// error parsing means that the suffix at the end was not generated
 		// by lumberjack, and therefore it's not a backup file.
 	}
 
+	if l.NumericBackups {
+		logFiles = append(logFiles, l.numberedLogFiles(files)...)
+	}
+
 	sort.Sort(byFormatTime(logFiles))
 
 	return logFiles, nil
@@ -438,7 +1762,11 @@ func (l *Logger) timeFromName(filename, prefix, ext string) (time.Time, error) {
 		return time.Time{}, errors.New("mismatched extension")
 	}
 	ts := filename[len(prefix) : len(filename)-len(ext)]
-	return time.Parse(backupTimeFormat, ts)
+	loc := time.UTC
+	if l.LocalTime {
+		loc = time.Local
+	}
+	return time.ParseInLocation(backupTimeFormat, ts, loc)
 }
 
 // max returns the maximum size in bytes of log files before rolling.
@@ -449,73 +1777,181 @@ func (l *Logger) max() int64 {
 	return int64(l.MaxSize) * int64(megabyte)
 }
 
+// decorate returns the log file name that Filename or FallbackFilename, name,
+// stands for, with environment variables and times filled in and ".gz"
+// added, as the configuration asks.
+func (l *Logger) decorate(name string) string {
+	name = l.timed(l.expanded(name))
+	if l.CompressActive && !strings.HasSuffix(name, compressSuffix) {
+		name += compressSuffix
+	}
+	return l.resolved(name)
+}
+
+// backupName returns the name to move the log file, name, aside to.  With
+// CompressActive, the timestamp goes before the extension that ".gz" was
+// added to.  With NumericBackups, it is always backup number 1.
+func (l *Logger) backupName(name string) string {
+	if l.NumericBackups {
+		return l.numberedBackupName(name)
+	}
+	t := l.rotateTime
+	if t.IsZero() {
+		t = currentTime()
+	}
+	if l.CompressActive {
+		return backupNameAt(strings.TrimSuffix(name, compressSuffix), t, l.LocalTime) + compressSuffix
+	}
+	return backupNameAt(name, t, l.LocalTime)
+}
+
 // dir returns the directory for the current filename.
 func (l *Logger) dir() string {
 	return filepath.Dir(l.filename())
 }
 
+// stat calls Stat if it is set, or os.Stat.
+func (l *Logger) stat(name string) (os.FileInfo, error) {
+	if l.Stat != nil {
+		return l.Stat(name)
+	}
+	return os.Stat(name)
+}
+
+// rename calls Rename if it is set, or os.Rename.
+func (l *Logger) rename(oldpath, newpath string) error {
+	if l.Rename != nil {
+		return l.Rename(oldpath, newpath)
+	}
+	return os.Rename(oldpath, newpath)
+}
+
+// renameBackup moves the log file oldpath to its backup name newpath, like
+// rename, except that if there is already a file called newpath it fails with
+// an error wrapping os.ErrExist rather than overwriting that backup.
+func (l *Logger) renameBackup(oldpath, newpath string) error {
+	if err := l.checkBackup(newpath); err != nil {
+		return err
+	}
+	if l.Rename != nil {
+		return l.Rename(oldpath, newpath)
+	}
+	unchecked, err := renameNoReplace(oldpath, newpath)
+	if err == nil && unchecked != nil && atomic.CompareAndSwapInt32(&l.renameUnchecked, 0, 1) {
+		l.emit(Event{Type: EventRenameUnchecked, Filename: newpath, Err: unchecked})
+	}
+	return err
+}
+
+// errRenameUnchecked is why renameNoReplace had to check and rename in two
+// steps on a platform that has no way to do it in one.
+var errRenameUnchecked = errors.New("renaming without replacing is not supported on this platform")
+
+// checkedRename renames oldpath to newpath if there is no file called
+// newpath, for where that can't be done in one step.
+func checkedRename(oldpath, newpath string) error {
+	if _, err := os.Lstat(newpath); !os.IsNotExist(err) {
+		if err == nil {
+			err = os.ErrExist
+		}
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	return os.Rename(oldpath, newpath)
+}
+
 // prefixAndExt returns the filename part and extension part from the Logger's
 // filename.
 func (l *Logger) prefixAndExt() (prefix, ext string) {
 	filename := filepath.Base(l.filename())
 	ext = filepath.Ext(filename)
+	if l.CompressActive {
+		ext = filepath.Ext(strings.TrimSuffix(filename, ext)) + ext
+	}
 	prefix = filename[:len(filename)-len(ext)] + "-"
 	return prefix, ext
 }
 
-// compressLogFile compresses the given log file, removing the
-// uncompressed log file if successful.
-func compressLogFile(src, dst string) (err error) {
-	f, err := os.Open(src)
+// compressLogFile compresses the given log file with c, removing the
+// uncompressed log file if successful.  If keep is set, the log file's name
+// and modification time are recorded in the compressed file.
+func (l *Logger) compressLogFile(src, dst string, c *codec, keep bool) (err error) {
+	f, err := os.OpenFile(src, os.O_RDONLY|l.noFollow(), 0)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %v", err)
+		return fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer f.Close()
 
-	fi, err := osStat(src)
+	fi, err := l.stat(src)
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	tmp, err := l.workName(dst)
 	if err != nil {
-		return fmt.Errorf("failed to stat log file: %v", err)
+		return fmt.Errorf("failed to make work directory: %w", err)
+	}
+
+	if l.chunked(c) {
+		err = l.compressChunks(f, tmp, fi, keep)
+		if err == nil {
+			err = l.placeCompressed(f, src, tmp, dst)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to compress log file: %w", err)
+		}
+		return nil
 	}
 
-	if err := chown(dst, fi); err != nil {
-		return fmt.Errorf("failed to chown compressed log file: %v", err)
+	if err := l.chown(tmp, fi); err != nil {
+		return fmt.Errorf("failed to chown compressed log file: %w", err)
 	}
 
 	// If this file already exists, we presume it was created by
 	// a previous attempt to compress the log file.
-	gzf, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, fi.Mode())
+	gzf, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|l.noFollow(), fi.Mode())
 	if err != nil {
-		return fmt.Errorf("failed to open compressed log file: %v", err)
+		return fmt.Errorf("failed to open compressed log file: %w", err)
 	}
 	defer gzf.Close()
 
-	gz := gzip.NewWriter(gzf)
-
 	defer func() {
 		if err != nil {
-			os.Remove(dst)
-			err = fmt.Errorf("failed to compress log file: %v", err)
+			os.Remove(tmp)
+			err = fmt.Errorf("failed to compress log file: %w", err)
 		}
 	}()
 
-	if _, err := io.Copy(gz, f); err != nil {
+	if err := c.compress(gzf, l.compressSource(f), fi, keep); err != nil {
 		return err
 	}
-	if err := gz.Close(); err != nil {
-		return err
+	if l.SyncCompressed {
+		if err := gzf.Sync(); err != nil {
+			return err
+		}
 	}
 	if err := gzf.Close(); err != nil {
 		return err
 	}
+	return l.placeCompressed(f, src, tmp, dst)
+}
 
-	if err := f.Close(); err != nil {
-		return err
+// placeCompressed moves the finished compressed backup tmp to dst, if they
+// differ, then closes and removes the backup src it was made from, which is
+// open as f.
+func (l *Logger) placeCompressed(f *os.File, src, tmp, dst string) error {
+	if tmp != dst {
+		if err := os.Rename(tmp, dst); err != nil {
+			return err
+		}
 	}
-	if err := os.Remove(src); err != nil {
-		return err
+	if l.SyncCompressed {
+		syncDir(filepath.Dir(dst))
 	}
 
-	return nil
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(src)
 }
 
 // logInfo is a convenience struct to return the filename and its embedded
@@ -525,11 +1961,20 @@ type logInfo struct {
 	os.FileInfo
 }
 
-// byFormatTime sorts by newest time formatted in the name.
+// byFormatTime sorts by newest time formatted in the name.  Backups with the
+// same time, because of a coarse time format or a clock that was set back,
+// are sorted by newest modification time and then by name, so that which one
+// is removed first doesn't depend on the order they were read in.
 type byFormatTime []logInfo
 
 func (b byFormatTime) Less(i, j int) bool {
-	return b[i].timestamp.After(b[j].timestamp)
+	if !b[i].timestamp.Equal(b[j].timestamp) {
+		return b[i].timestamp.After(b[j].timestamp)
+	}
+	if mi, mj := b[i].ModTime(), b[j].ModTime(); !mi.Equal(mj) {
+		return mi.After(mj)
+	}
+	return b[i].Name() > b[j].Name()
 }
 
 func (b byFormatTime) Swap(i, j int) {
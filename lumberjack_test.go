@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -21,9 +27,16 @@ import (
 // Since all the tests uses the time to determine filenames etc, we need to
 // control the wall clock as much as possible, which means having a wall clock
 // that doesn't change unless we want it to.
-var fakeCurrentTime = time.Now()
+// fakeTimeMu guards fakeCurrentTime while it's moved on by newFakeTime, since
+// the mill reads it in the background.
+var (
+	fakeCurrentTime = time.Now()
+	fakeTimeMu      sync.Mutex
+)
 
 func fakeTime() time.Time {
+	fakeTimeMu.Lock()
+	defer fakeTimeMu.Unlock()
 	return fakeCurrentTime
 }
 
@@ -374,6 +387,99 @@ func TestCleanupExistingBackups(t *testing.T) {
 	fileCount(dir, 2, t)
 }
 
+func TestRemoveBacklog(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRemoveBacklog", t)
+	defer os.RemoveAll(dir)
+
+	// many more backups than the mill removes at once.
+	var names []string
+	for i := 0; i < 3*millRemovers; i++ {
+		newFakeTime()
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		names = append(names, name)
+	}
+
+	var removed int64
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxBackups:    2,
+		Compress:      true,
+		ManualCleanup: true,
+		OnEvent: func(e Event) {
+			if e.Type == EventRemove && e.Err == nil {
+				atomic.AddInt64(&removed, 1)
+			}
+		},
+	}
+	defer l.Close()
+
+	isNil(l.CleanNow(), t)
+	equals(int64(len(names)-2), atomic.LoadInt64(&removed), t)
+	equals(len(names)-2, l.Stats().LastMill.Removed, t)
+	equals(2, l.Stats().LastMill.Compressed, t)
+	for _, name := range names[:len(names)-2] {
+		notExist(name, t)
+	}
+	for _, name := range names[len(names)-2:] {
+		notExist(name, t)
+		exists(name+compressSuffix, t)
+	}
+}
+
+func TestRemoveEventsInOrder(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRemoveEventsInOrder", t)
+	defer os.RemoveAll(dir)
+
+	var names []string
+	for i := 0; i < 3*millRemovers; i++ {
+		newFakeTime()
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		names = append(names, name)
+	}
+
+	// OnEvent may be called from more than one goroutine at once, so the
+	// removals are collected under a lock.
+	var mu sync.Mutex
+	var removed []string
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 2,
+		Compress:   true,
+		OnEvent: func(e Event) {
+			if e.Type == EventRemove {
+				isNil(e.Err, t)
+				mu.Lock()
+				removed = append(removed, e.Filename)
+				mu.Unlock()
+			}
+		},
+	}
+	isNil(l.Rotate(), t)
+
+	// wait for the mill to remove the backups, then stop it.
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(names[len(names)-3]); os.IsNotExist(err) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	isNil(l.Close(), t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals(len(names)-2, len(removed), t)
+	for i, name := range names[:len(names)-2] {
+		notExist(name, t)
+		equals(name, removed[len(removed)-1-i], t)
+	}
+}
+
 func TestMaxAge(t *testing.T) {
 	currentTime = fakeTime
 	megabyte = 1
@@ -636,6 +742,40 @@ func TestCompressOnRotate(t *testing.T) {
 	fileCount(dir, 2, t)
 }
 
+func TestCompressMinSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressMinSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress:        true,
+		CompressMinSize: 5,
+		Filename:        logFile(dir),
+		ManualCleanup:   true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	small := backupFile(dir)
+
+	_, err = l.Write([]byte("booooo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	big := backupFile(dir)
+
+	isNil(l.CleanNow(), t)
+	existsWithContent(small, []byte("boo!"), t)
+	notExist(small+compressSuffix, t)
+	notExist(big, t)
+	exists(big+compressSuffix, t)
+}
+
 func TestCompressOnResume(t *testing.T) {
 	currentTime = fakeTime
 	megabyte = 1
@@ -685,6 +825,41 @@ func TestCompressOnResume(t *testing.T) {
 	fileCount(dir, 2, t)
 }
 
+func TestCompressExistingBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestCompressExistingBackups", t)
+	defer os.RemoveAll(dir)
+
+	// backups left uncompressed by a run without Compress.
+	filename := logFile(dir)
+	var backups []string
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		backups = append(backups, backupFile(dir))
+		isNil(ioutil.WriteFile(backupFile(dir), []byte("old"), 0644), t)
+	}
+	isNil(ioutil.WriteFile(filename, []byte("boo!"), 0644), t)
+
+	l := &Logger{
+		Compress: true,
+		Filename: filename,
+		MaxSize:  10,
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	// Close waits for the cleanup started by opening the log file.
+	isNil(l.Close(), t)
+
+	existsWithContent(filename, []byte("boo!boo!"), t)
+	for _, b := range backups {
+		notExist(b, t)
+		exists(b+compressSuffix, t)
+	}
+	fileCount(dir, 4, t)
+}
+
 func TestJson(t *testing.T) {
 	data := []byte(`
 {
@@ -758,6 +933,8 @@ func fileCount(dir string, exp int, t testing.TB) {
 
 // newFakeTime sets the fake "current time" to two days later.
 func newFakeTime() {
+	fakeTimeMu.Lock()
+	defer fakeTimeMu.Unlock()
 	fakeCurrentTime = fakeCurrentTime.Add(time.Hour * 24 * 2)
 }
 
@@ -770,3 +947,403 @@ func exists(path string, t testing.TB) {
 	_, err := os.Stat(path)
 	assertUp(err == nil, t, 1, "expected file to exist, but got error from os.Stat: %v", err)
 }
+
+func TestLastError(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestLastError", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress: true,
+		Filename: filename,
+		MaxSize:  10,
+	}
+	defer l.Close()
+	isNil(l.LastError(), t)
+
+	// A directory in the way of the compressed file makes compression fail.
+	filename2 := backupFile(dir)
+	err := ioutil.WriteFile(filename2, []byte("foo!"), 0644)
+	isNil(err, t)
+	err = os.Mkdir(filename2+compressSuffix, 0700)
+	isNil(err, t)
+
+	newFakeTime()
+
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// we need to wait a little bit since the files get compressed on a different
+	// goroutine.
+	<-time.After(300 * time.Millisecond)
+	notNil(l.LastError(), t)
+	exists(filename2, t)
+
+	err = os.Remove(filename2 + compressSuffix)
+	isNil(err, t)
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+
+	<-time.After(300 * time.Millisecond)
+	isNil(l.LastError(), t)
+	notExist(filename2, t)
+}
+
+func TestStrictErrors(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestStrictErrors", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Compress:      true,
+		Filename:      filename,
+		MaxSize:       10,
+		ManualCleanup: true,
+		StrictErrors:  true,
+	}
+	defer l.Close()
+
+	// A directory in the way of the compressed file makes compression fail.
+	filename2 := backupFile(dir)
+	err := ioutil.WriteFile(filename2, []byte("foo!"), 0644)
+	isNil(err, t)
+	err = os.Mkdir(filename2+compressSuffix, 0700)
+	isNil(err, t)
+
+	millErr := l.CleanNow()
+	notNil(millErr, t)
+
+	// the next write reports the error, but still writes its data.
+	b := []byte("boo!")
+	n, err := l.Write(b)
+	assert(errors.Is(err, millErr), t, "expected %v to wrap %v", err, millErr)
+	equals(len(b), n, t)
+	existsWithContent(filename, b, t)
+
+	// and the one after that doesn't report it again.
+	_, err = l.Write(b)
+	isNil(err, t)
+	notNil(l.LastError(), t)
+}
+
+func TestCloseReportsMillErrors(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCloseReportsMillErrors", t)
+	defer os.RemoveAll(dir)
+
+	// A directory in the way of the compressed file makes compression fail.
+	filename2 := backupFile(dir)
+	err := ioutil.WriteFile(filename2, []byte("foo!"), 0644)
+	isNil(err, t)
+	err = os.Mkdir(filename2+compressSuffix, 0700)
+	isNil(err, t)
+
+	l := &Logger{
+		Compress:      true,
+		Filename:      logFile(dir),
+		ReopenOnWrite: true,
+	}
+	defer l.Close()
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// Close waits for the cleanup that the write started, and reports its
+	// error once.
+	err = l.Close()
+	notNil(err, t)
+	assert(strings.Contains(err.Error(), filename2), t, "error %q doesn't mention %s", err, filename2)
+	isNil(l.Close(), t)
+
+	// the mill starts again when the Logger is used again.
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+	notNil(l.Close(), t)
+}
+
+func TestMillJoinsErrors(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMillJoinsErrors", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Compress: true,
+		Filename: logFile(dir),
+	}
+	defer l.Close()
+
+	// directories in the way of both compressed files make both fail.
+	var names []string
+	for i := 0; i < 2; i++ {
+		newFakeTime()
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("foo!"), 0644), t)
+		isNil(os.Mkdir(name+compressSuffix, 0700), t)
+		names = append(names, name)
+	}
+
+	err := l.millRunOnce()
+	notNil(err, t)
+	for _, name := range names {
+		assert(strings.Contains(err.Error(), name), t, "error %q doesn't mention %s", err, name)
+	}
+}
+
+func TestManualCleanup(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestManualCleanup", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxBackups:    1,
+		Compress:      true,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := backupFile(dir)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(10 * time.Millisecond)
+	// nothing happens in the background.
+	fileCount(dir, 3, t)
+	isNil(l.millCh, t)
+
+	isNil(l.CleanNow(), t)
+	notExist(first, t)
+	exists(backupFile(dir)+compressSuffix, t)
+	fileCount(dir, 2, t)
+}
+
+func TestRemoveEmpty(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestRemoveEmpty", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:      filename,
+		MaxSize:       10,
+		RemoveEmpty:   true,
+		ReopenOnWrite: true,
+		Header: func(w io.Writer) error {
+			_, err := w.Write([]byte("hdr\n"))
+			return err
+		},
+	}
+
+	// a file with only its header is removed.
+	isNil(l.Rotate(), t)
+	existsWithContent(filename, []byte("hdr\n"), t)
+	isNil(l.Close(), t)
+	notExist(filename, t)
+
+	// one with something written to it isn't.
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("hdr\nboo!"), t)
+
+	// and nor is one that was already there.
+	isNil(ioutil.WriteFile(filename, nil, 0644), t)
+	l.Header = nil
+	_, err = l.Write(nil)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	exists(filename, t)
+
+	// closing twice is harmless.
+	isNil(l.Close(), t)
+}
+
+func TestRenameHook(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRenameHook", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	var renamed []string
+	l := &Logger{
+		Filename: filename,
+		Rename: func(oldpath, newpath string) error {
+			renamed = append(renamed, oldpath, newpath)
+			return os.Rename(oldpath, newpath)
+		},
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	equals([]string{filename, backupFile(dir)}, renamed, t)
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+
+	// a failed rename stops the rotation.
+	l.Rename = func(string, string) error { return errors.New("nope") }
+	newFakeTime()
+	notNil(l.Rotate(), t)
+	notExist(backupFile(dir), t)
+}
+
+func TestRotateKeepsExistingBackup(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestRotateKeepsExistingBackup", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename: filename,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	old := []byte("old backup")
+	err = ioutil.WriteFile(backupFile(dir), old, 0644)
+	isNil(err, t)
+
+	err = l.Rotate()
+	notNil(err, t)
+	assert(errors.Is(err, os.ErrExist), t, "expected os.ErrExist, got %v", err)
+	existsWithContent(backupFile(dir), old, t)
+	existsWithContent(filename, []byte("boo!"), t)
+}
+
+func TestSortSameTimestamp(t *testing.T) {
+	dir := makeTempDir("TestSortSameTimestamp", t)
+	defer os.RemoveAll(dir)
+
+	ts := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var files []logInfo
+	for i, name := range []string{"a.log", "b.log", "c.log", "d.log"} {
+		fn := filepath.Join(dir, name)
+		isNil(ioutil.WriteFile(fn, []byte(name), 0644), t)
+		// b and c were written to at the same time.
+		mtime := ts.Add(time.Duration(i) * time.Minute)
+		if name == "c.log" {
+			mtime = ts.Add(time.Minute)
+		}
+		isNil(os.Chtimes(fn, mtime, mtime), t)
+		info, err := os.Stat(fn)
+		isNil(err, t)
+		files = append(files, logInfo{ts, info})
+	}
+	for _, order := range [][]int{{0, 1, 2, 3}, {3, 2, 1, 0}, {2, 0, 3, 1}} {
+		shuffled := make([]logInfo, len(files))
+		for i, j := range order {
+			shuffled[i] = files[j]
+		}
+		sort.Sort(byFormatTime(shuffled))
+		var names []string
+		for _, f := range shuffled {
+			names = append(names, f.Name())
+		}
+		equals([]string{"d.log", "c.log", "b.log", "a.log"}, names, t)
+	}
+}
+
+func TestRotateReason(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestRotateReason", t)
+	defer os.RemoveAll(dir)
+
+	var reasons []RotateReason
+	var events []Event
+	l := &Logger{
+		Filename:       logFile(dir),
+		MaxSize:        10,
+		RotateInterval: time.Hour,
+		OnRotate:       func(_ string, r RotateReason) { reasons = append(reasons, r) },
+		OnEvent: func(e Event) {
+			if e.Type == EventRotate {
+				events = append(events, e)
+			}
+		},
+	}
+	defer l.Close()
+
+	write := func(s string) {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+	}
+	write("12345678")
+	newFakeTime()
+	write("12345")
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	write("1")
+	newFakeTime()
+	write("1")
+
+	// a Logger that finds the log file too big to add to.
+	write("12345678")
+	l2 := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+		OnRotate: func(_ string, r RotateReason) { reasons = append(reasons, r) },
+	}
+	defer l2.Close()
+	newFakeTime()
+	_, err := l2.Write([]byte("12345"))
+	isNil(err, t)
+
+	equals([]RotateReason{RotateSize, RotateManual, RotateSchedule, RotateStartup}, reasons, t)
+	equals(3, len(events), t)
+	equals(RotateSize, events[0].Reason, t)
+
+	b, err := json.Marshal(events[1])
+	isNil(err, t)
+	assert(strings.Contains(string(b), `"reason":"manual"`), t, "expected the reason in %s", b)
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteAfterClose", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	isNil(l.Close(), t)
+
+	n, err := l.Write(b)
+	assert(errors.Is(err, ErrClosed), t, "expected ErrClosed, got %v", err)
+	assert(errors.Is(err, os.ErrClosed), t, "expected os.ErrClosed, got %v", err)
+	equals(0, n, t)
+	assert(errors.Is(l.Rotate(), ErrClosed), t, "expected ErrClosed from Rotate")
+	g := &Group{Loggers: []*Logger{l}}
+	assert(errors.Is(g.RotateAll(), ErrClosed), t, "expected ErrClosed from RotateAll")
+	other := filepath.Join(dir, "other.log")
+	isNil(ioutil.WriteFile(other, b, 0644), t)
+	_, err = l.Adopt(other)
+	assert(errors.Is(err, ErrClosed), t, "expected ErrClosed from Adopt, got %v", err)
+	existsWithContent(logFile(dir), b, t)
+	fileCount(dir, 2, t)
+
+	// with ReopenOnWrite, the log file is reopened instead.
+	l.ReopenOnWrite = true
+	_, err = l.Write(b)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(logFile(dir), []byte("boo!boo!"), t)
+}
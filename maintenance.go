@@ -0,0 +1,94 @@
+package lumberjack
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceWindow is a span of each day, such as 02:00 to 05:00, outside
+// which the background compression and removal of old log files waits, so
+// that it doesn't compete for I/O with the busy hours.  Times of day are
+// reckoned in the Logger's TimeZone.
+type MaintenanceWindow struct {
+	// Start and End are the times of day that the window opens and closes,
+	// as offsets from midnight.  If End is before Start, the window spans
+	// midnight.
+	Start time.Duration `json:"start" yaml:"start"`
+	End   time.Duration `json:"end" yaml:"end"`
+}
+
+// until returns how long after t the window next opens, or zero if it is
+// open at t.
+func (w *MaintenanceWindow) until(t time.Time) time.Duration {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	now := t.Sub(midnight)
+	open := now >= w.Start && now < w.End
+	if w.End < w.Start {
+		open = now >= w.Start || now < w.End
+	}
+	if open {
+		return 0
+	}
+	if now < w.Start {
+		return w.Start - now
+	}
+	return 24*time.Hour - now + w.Start
+}
+
+// waitForWindow waits until the MaintenanceWindow, if any, is open, or stop
+// is closed, reporting whether it is time to do the work.
+func (l *Logger) waitForWindow(stop chan struct{}) bool {
+	if l.MaintenanceWindow == nil {
+		return true
+	}
+	loc, err := l.location()
+	if err != nil {
+		// the mill will report the error.
+		return true
+	}
+	d := l.MaintenanceWindow.until(currentTime().In(loc))
+	if d == 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-stop:
+		return false
+	}
+}
+
+// PauseMaintenance stops the compression and removal of old log files, by
+// the Logger, its DiskPressure check or a DiskBudget it shares, until
+// ResumeMaintenance is called, so that nothing is deleted and no extra I/O is
+// done while an incident is investigated, say.  Logging carries on as normal,
+// including rotation, and so does PurgeOnNoSpace, which only removes backups
+// to keep logging going.  Any cleanup that is under way is finished before
+// PauseMaintenance returns.
+func (l *Logger) PauseMaintenance() {
+	atomic.StoreInt32(&l.paused, 1)
+	l.millMu.Lock()
+	l.millMu.Unlock()
+}
+
+// ResumeMaintenance undoes PauseMaintenance, starting a round of the cleanup
+// that was put off in the background, unless ManualCleanup is set or the
+// Logger has been closed.
+func (l *Logger) ResumeMaintenance() {
+	if !atomic.CompareAndSwapInt32(&l.paused, 1, 0) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+	l.mill()
+}
+
+// maintenancePaused reports whether PauseMaintenance is in effect.
+func (l *Logger) maintenancePaused() bool {
+	return atomic.LoadInt32(&l.paused) == 1
+}
@@ -0,0 +1,117 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceWindowUntil(t *testing.T) {
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := &MaintenanceWindow{Start: 2 * time.Hour, End: 5 * time.Hour}
+	equals(2*time.Hour, w.until(day), t)
+	equals(time.Duration(0), w.until(day.Add(3*time.Hour)), t)
+	equals(21*time.Hour, w.until(day.Add(5*time.Hour)), t)
+
+	// a window that spans midnight.
+	w = &MaintenanceWindow{Start: 22 * time.Hour, End: 2 * time.Hour}
+	equals(time.Duration(0), w.until(day.Add(time.Hour)), t)
+	equals(time.Duration(0), w.until(day.Add(23*time.Hour)), t)
+	equals(10*time.Hour, w.until(day.Add(12*time.Hour)), t)
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestMaintenanceWindow", t)
+	defer os.RemoveAll(dir)
+
+	// a window that opens shortly.
+	now := fakeTime().UTC()
+	tod := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	l := &Logger{
+		Filename: logFile(dir),
+		Compress: true,
+		MaintenanceWindow: &MaintenanceWindow{
+			Start: (tod + 300*time.Millisecond) % (24 * time.Hour),
+			End:   (tod + time.Hour) % (24 * time.Hour),
+		},
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(100 * time.Millisecond)
+	existsWithContent(backupFile(dir), b, t)
+
+	<-time.After(600 * time.Millisecond)
+	notExist(backupFile(dir), t)
+	exists(backupFile(dir)+compressSuffix, t)
+
+	// cleanup waiting for the window is dropped by Close.
+	l.MaintenanceWindow.Start = (tod + time.Hour) % (24 * time.Hour)
+	l.MaintenanceWindow.End = (tod + 2*time.Hour) % (24 * time.Hour)
+	_, err = l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.Close(), t)
+	existsWithContent(backupFile(dir), b, t)
+}
+
+func TestPauseMaintenance(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestPauseMaintenance", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	defer l.Close()
+	l.PauseMaintenance()
+
+	b := []byte("boo!")
+	var backups []string
+	for i := 0; i < 3; i++ {
+		_, err := l.Write(b)
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		backups = append(backups, backupFile(dir))
+	}
+	isNil(l.CleanNow(), t)
+	<-time.After(10 * time.Millisecond)
+	fileCount(dir, 4, t)
+
+	// resuming catches up on the cleanup that was put off.
+	l.ResumeMaintenance()
+	<-time.After(10 * time.Millisecond)
+	notExist(backups[0], t)
+	notExist(backups[1], t)
+	exists(backups[2], t)
+	fileCount(dir, 2, t)
+}
+
+func TestResumeMaintenanceAfterClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestResumeMaintenanceAfterClose", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+	}
+	l.PauseMaintenance()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	// nothing is left running once the Logger is closed.
+	l.ResumeMaintenance()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	assert(l.millCh == nil, t, "expected no mill goroutine after Close")
+}
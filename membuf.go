@@ -0,0 +1,106 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBufferOverflow is wrapped by the error in the EventReplay event when
+// log data held in memory because of MemoryBuffer had to be dropped because
+// there was too much of it.
+var ErrBufferOverflow = errors.New("memory buffer overflowed")
+
+// memBuffer holds log data that couldn't be written, up to size bytes,
+// dropping the oldest data to make room for more.
+type memBuffer struct {
+	size int
+	buf  []byte
+
+	// lost is how many bytes have been dropped.
+	lost int64
+}
+
+// add appends p to the buffer, dropping whatever it must from the front.
+func (b *memBuffer) add(p []byte) {
+	if over := len(b.buf) + len(p) - b.size; over > 0 {
+		if over >= len(b.buf) {
+			over -= len(b.buf)
+			b.lost += int64(len(b.buf) + over)
+			b.buf = b.buf[:0]
+			p = p[over:]
+		} else {
+			b.lost += int64(over)
+			b.buf = b.buf[:copy(b.buf, b.buf[over:])]
+		}
+	}
+	b.buf = append(b.buf, p...)
+}
+
+// take empties the buffer, returning what it held and how many bytes had
+// been dropped.
+func (b *memBuffer) take() (data []byte, lost int64) {
+	data, lost = b.buf, b.lost
+	b.buf, b.lost = nil, 0
+	return data, lost
+}
+
+// bufferWrite finishes a write of p, of which n bytes reached the log file
+// before err.  If err is set and MemoryBuffer is on, the rest of p is kept in
+// memory to be written to the log file later, and the write counts as a
+// success.  Otherwise it is handed on to writeStderr.
+func (l *Logger) bufferWrite(p []byte, n int, err error) (int, error) {
+	if err == nil || l.MemoryBuffer <= 0 {
+		return l.writeStderr(p, n, err)
+	}
+	if l.membuf == nil {
+		l.membuf = &memBuffer{size: l.MemoryBuffer * megabyte}
+	}
+	if !l.buffering {
+		l.buffering = true
+		l.emit(Event{Type: EventBuffer, Filename: l.primaryFilename(), Err: err})
+	}
+	l.membuf.add(p[n:])
+	return len(p), nil
+}
+
+// replay writes the log data held in memory because of MemoryBuffer to the
+// log file, ahead of anything written since.  If that fails, whatever wasn't
+// written goes back into memory.
+func (l *Logger) replay() {
+	if !l.buffering || l.replaying {
+		return
+	}
+	l.replaying = true
+	defer func() { l.replaying = false }()
+
+	data, lost := l.membuf.take()
+	size := int64(len(data))
+	for len(data) > 0 {
+		chunk := data
+		if int64(len(chunk)) > l.max() {
+			chunk = chunk[:l.max()]
+		}
+		data = data[len(chunk):]
+		_, err := l.write(chunk)
+		if err != nil && len(l.membuf.buf) == 0 {
+			// the write failed before it got as far as holding on to
+			// chunk, as after Close, so keep it all for next time.
+			l.membuf.add(chunk)
+			l.membuf.add(data)
+			l.membuf.lost += lost
+			return
+		}
+		if len(l.membuf.buf) > 0 {
+			// the write failed, and the rest of chunk is back in memory.
+			l.membuf.add(data)
+			l.membuf.lost += lost
+			return
+		}
+	}
+	l.buffering = false
+	e := Event{Type: EventReplay, Filename: l.filename(), Size: size}
+	if lost > 0 {
+		e.Err = fmt.Errorf("%w: %d bytes of log data were lost", ErrBufferOverflow, lost)
+	}
+	l.emit(e)
+}
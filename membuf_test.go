@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMemoryBuffer(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMemoryBuffer", t)
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+	filename := filepath.Join(blocker, "foobar.log")
+
+	var events []Event
+	l := &Logger{
+		Filename:     filename,
+		MemoryBuffer: 12,
+		OnEvent:      func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	for _, s := range []string{"one\n", "two\n", "three\n"} {
+		n, err := l.Write([]byte(s))
+		isNil(err, t)
+		equals(len(s), n, t)
+	}
+	equals(1, len(events), t)
+	equals(EventBuffer, events[0].Type, t)
+	notNil(events[0].Err, t)
+
+	// once the file works again, what's buffered is written first, less
+	// what didn't fit.
+	err = os.Remove(blocker)
+	isNil(err, t)
+	_, err = l.Write([]byte("four\n"))
+	isNil(err, t)
+	existsWithContent(filename, []byte("e\ntwo\nthree\nfour\n"), t)
+
+	equals(2, len(events), t)
+	equals(EventReplay, events[1].Type, t)
+	equals(int64(12), events[1].Size, t)
+	assert(errors.Is(events[1].Err, ErrBufferOverflow), t, "expected ErrBufferOverflow, got %v", events[1].Err)
+}
+
+func TestMemoryBufferClose(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMemoryBufferClose", t)
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+	filename := filepath.Join(blocker, "foobar.log")
+
+	l := &Logger{
+		Filename:     filename,
+		MemoryBuffer: 100,
+	}
+	_, err = l.Write([]byte("boo!\n"))
+	isNil(err, t)
+
+	err = os.Remove(blocker)
+	isNil(err, t)
+	isNil(l.Close(), t)
+	existsWithContent(filename, []byte("boo!\n"), t)
+}
+
+func TestMemoryBufferCloseAgain(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMemoryBufferCloseAgain", t)
+	defer os.RemoveAll(dir)
+
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+	filename := filepath.Join(blocker, "foobar.log")
+
+	var events []Event
+	l := &Logger{
+		Filename:     filename,
+		MemoryBuffer: 100,
+		OnEvent:      func(e Event) { events = append(events, e) },
+	}
+	_, err = l.Write([]byte("boo!\n"))
+	isNil(err, t)
+	isNil(l.Close(), t)
+
+	// the Logger is closed, so a second Close can't write what's buffered,
+	// and doesn't claim to have.
+	err = os.Remove(blocker)
+	isNil(err, t)
+	l.Close()
+	notExist(filename, t)
+	equals([]byte("boo!\n"), l.membuf.buf, t)
+	for _, e := range events {
+		assert(e.Type != EventReplay, t, "unexpected replay event %v", e)
+	}
+}
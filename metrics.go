@@ -0,0 +1,122 @@
+package lumberjack
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives counts of what a Logger does, so that they can be passed
+// on to any metrics system without lumberjack depending on it.  Its methods
+// may be called from background goroutines, more than one at once, or while
+// the Logger holds its lock, so they must be quick and safe for concurrent
+// use, and must not call back into the Logger.
+type Metrics interface {
+	// BytesWritten is called with the number of bytes each Write wrote.
+	BytesWritten(n int)
+
+	// Rotated is called when the log file has been moved aside to become a
+	// backup.
+	Rotated()
+
+	// Compressed is called when a backup has been compressed, with how long
+	// it took and the compressed size in bytes.
+	Compressed(d time.Duration, size int64)
+
+	// Removed is called when a backup has been removed.
+	Removed()
+
+	// Error is called with errors returned by Write and errors from
+	// rotation, compression, removal and the like.
+	Error(err error)
+}
+
+// LabeledMetrics is a Metrics that can be told the Logger's Labels.  If
+// Metrics is one, WithLabels is called once, before anything is recorded,
+// and what it returns is used instead, so one Metrics can be shared by
+// several Loggers and still keep their counts apart.
+type LabeledMetrics interface {
+	Metrics
+
+	// WithLabels returns a Metrics that records with labels attached.
+	WithLabels(labels map[string]string) Metrics
+}
+
+// labeledMetrics returns the Metrics to record to, or nil if there isn't
+// one.
+func (l *Logger) labeledMetrics() Metrics {
+	l.metricsOnce.Do(func() {
+		l.metrics = l.Metrics
+		if lm, ok := l.Metrics.(LabeledMetrics); ok {
+			l.metrics = lm.WithLabels(l.Labels)
+		}
+	})
+	return l.metrics
+}
+
+// instrumentWrite writes p with write, passing what happened on to Metrics,
+// WriteLatency and WriteSample.  Every way of writing to the Logger goes
+// through it once.
+func (l *Logger) instrumentWrite(p []byte, write func([]byte) (int, error)) (int, error) {
+	defer l.timeWrite(time.Now(), atomic.LoadInt64(&l.rotations))
+	l.sampleWrite(len(p))
+	n, err := write(p)
+	l.recordWrite(n, err)
+	return n, err
+}
+
+// recordWrite passes the result of a Write on to Metrics, if it is set.
+func (l *Logger) recordWrite(n int, err error) {
+	m := l.labeledMetrics()
+	if m == nil {
+		return
+	}
+	if n > 0 {
+		m.BytesWritten(n)
+	}
+	if err != nil {
+		m.Error(err)
+	}
+}
+
+// timeWrite passes the time since start, when rotations rotations had been
+// done, to WriteLatency, if it is set.
+func (l *Logger) timeWrite(start time.Time, rotations int64) {
+	if l.WriteLatency != nil {
+		l.WriteLatency(time.Since(start), atomic.LoadInt64(&l.rotations) != rotations)
+	}
+}
+
+// sampleWrite passes size, the length of a Write, on to WriteSample if it is
+// set and this is one of the writes it samples.
+func (l *Logger) sampleWrite(size int) {
+	if l.WriteSample == nil {
+		return
+	}
+	n := atomic.AddUint64(&l.sampled, 1)
+	if l.SampleEvery > 1 && n%uint64(l.SampleEvery) != 0 {
+		return
+	}
+	l.WriteSample(size)
+}
+
+// recordEvent passes e on to Metrics, if it is set.
+func (l *Logger) recordEvent(e Event) {
+	m := l.labeledMetrics()
+	if m == nil {
+		return
+	}
+	if e.Err != nil {
+		m.Error(e.Err)
+		if e.Type != EventPurge {
+			return
+		}
+	}
+	switch e.Type {
+	case EventRotate:
+		m.Rotated()
+	case EventCompress:
+		m.Compressed(e.Duration, e.Size)
+	case EventRemove, EventPurge:
+		m.Removed()
+	}
+}
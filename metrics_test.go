@@ -0,0 +1,215 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMetrics counts the calls made to it.
+type fakeMetrics struct {
+	mu         sync.Mutex
+	bytes      int
+	rotated    int
+	compressed int
+	removed    int
+	errs       []error
+}
+
+func (m *fakeMetrics) BytesWritten(n int) { m.mu.Lock(); m.bytes += n; m.mu.Unlock() }
+func (m *fakeMetrics) Rotated()           { m.mu.Lock(); m.rotated++; m.mu.Unlock() }
+func (m *fakeMetrics) Removed()           { m.mu.Lock(); m.removed++; m.mu.Unlock() }
+func (m *fakeMetrics) Error(err error)    { m.mu.Lock(); m.errs = append(m.errs, err); m.mu.Unlock() }
+func (m *fakeMetrics) Compressed(d time.Duration, size int64) {
+	m.mu.Lock()
+	m.compressed++
+	m.mu.Unlock()
+}
+
+func TestMetrics(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMetrics", t)
+	defer os.RemoveAll(dir)
+
+	m := &fakeMetrics{}
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		MaxBackups:    1,
+		Compress:      true,
+		ManualCleanup: true,
+		Metrics:       m,
+	}
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		_, err := l.Write([]byte("boo!boo!"))
+		isNil(err, t)
+	}
+	_, err := l.Write(make([]byte, 11))
+	notNil(err, t)
+	isNil(l.CleanNow(), t)
+
+	equals(24, m.bytes, t)
+	equals(2, m.rotated, t)
+	equals(1, m.compressed, t)
+	equals(1, m.removed, t)
+	equals(1, len(m.errs), t)
+
+	// errors from the mill are passed on too.
+	newFakeTime()
+	name := backupFile(dir)
+	isNil(ioutil.WriteFile(name, []byte("foo!"), 0644), t)
+	isNil(os.Mkdir(name+compressSuffix, 0700), t)
+	notNil(l.CleanNow(), t)
+	equals(2, len(m.errs), t)
+}
+
+func TestWriteLatency(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWriteLatency", t)
+	defer os.RemoveAll(dir)
+
+	var rotated []bool
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+		WriteLatency: func(d time.Duration, r bool) {
+			assert(d > 0, t, "expected a positive duration, got %v", d)
+			rotated = append(rotated, r)
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	_, err = l.Write(b)
+	isNil(err, t)
+	_, err = l.WriteAndSync(b[:1])
+	isNil(err, t)
+	equals([]bool{false, true, false}, rotated, t)
+}
+
+// labeledMetrics is a fakeMetrics that remembers the labels it was given.
+type labeledMetrics struct {
+	fakeMetrics
+	labels map[string]string
+}
+
+func (m *labeledMetrics) WithLabels(labels map[string]string) Metrics {
+	m.labels = labels
+	return m
+}
+
+func TestLabels(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestLabels", t)
+	defer os.RemoveAll(dir)
+
+	labels := map[string]string{"service": "api", "tenant": "acme"}
+	m := &labeledMetrics{}
+	var events []Event
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+		Metrics:  m,
+		Labels:   labels,
+		OnEvent:  func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!boo!"))
+	isNil(err, t)
+	newFakeTime()
+	_, err = l.Write([]byte("boo!boo!"))
+	isNil(err, t)
+
+	equals(labels, m.labels, t)
+	equals(16, m.bytes, t)
+	equals(1, m.rotated, t)
+	assert(len(events) > 0, t, "expected events")
+	for _, e := range events {
+		equals(labels, e.Labels, t)
+	}
+	b, err := json.Marshal(events[0])
+	isNil(err, t)
+	assert(strings.Contains(string(b), `"labels":{"service":"api","tenant":"acme"}`), t,
+		"expected labels in %s", b)
+}
+
+func TestWriteSample(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWriteSample", t)
+	defer os.RemoveAll(dir)
+
+	var sizes []int
+	l := &Logger{
+		Filename:    logFile(dir),
+		MaxSize:     100,
+		SampleEvery: 3,
+		WriteSample: func(size int) { sizes = append(sizes, size) },
+	}
+	defer l.Close()
+
+	for i := 1; i <= 7; i++ {
+		_, err := l.Write(make([]byte, i))
+		isNil(err, t)
+	}
+	equals([]int{3, 6}, sizes, t)
+
+	// oversized writes are sampled too, though they fail.
+	sizes = nil
+	l.SampleEvery = 0
+	_, err := l.Write(make([]byte, 101))
+	notNil(err, t)
+	equals([]int{101}, sizes, t)
+}
+
+func TestInstrumentedWritePaths(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestInstrumentedWritePaths", t)
+	defer os.RemoveAll(dir)
+
+	m := &fakeMetrics{}
+	var sizes []int
+	var timed int
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       100,
+		Heartbeat:     time.Hour,
+		HeartbeatLine: "beat",
+		Metrics:       m,
+		WriteSample:   func(size int) { sizes = append(sizes, size) },
+		WriteLatency:  func(time.Duration, bool) { timed++ },
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = l.WriteContext(ctx, []byte("two!\n"))
+	isNil(err, t)
+	_, err = l.WriteAndSync([]byte("three\n"))
+	isNil(err, t)
+	// heartbeats aren't the caller's writes, so they're left out.
+	newFakeTime()
+	l.beat(make(chan struct{}))
+
+	equals(15, m.bytes, t)
+	equals([]int{4, 5, 6}, sizes, t)
+	equals(3, timed, t)
+}
@@ -0,0 +1,22 @@
+package lumberjack
+
+// defaultNearLimitPercent is the percentage of MaxSize at which OnNearLimit
+// is called if NearLimitPercent isn't set.
+const defaultNearLimitPercent = 90
+
+// checkNearLimit calls OnNearLimit if the log file, which was prev bytes
+// before the latest write, has just grown past NearLimitPercent of MaxSize.
+func (l *Logger) checkNearLimit(prev int64) {
+	if l.OnNearLimit == nil || l.passthrough {
+		return
+	}
+	pct := l.NearLimitPercent
+	if pct <= 0 {
+		pct = defaultNearLimitPercent
+	}
+	max := l.max()
+	limit := max * int64(pct) / 100
+	if prev < limit && l.size >= limit {
+		l.OnNearLimit(l.size, max)
+	}
+}
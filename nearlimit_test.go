@@ -0,0 +1,41 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+)
+
+func TestOnNearLimit(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestOnNearLimit", t)
+	defer os.RemoveAll(dir)
+
+	var calls [][2]int64
+	l := &Logger{
+		Filename:         logFile(dir),
+		MaxSize:          100,
+		NearLimitPercent: 50,
+		OnNearLimit:      func(size, max int64) { calls = append(calls, [2]int64{size, max}) },
+	}
+	defer l.Close()
+
+	b := make([]byte, 30)
+	_, err := l.Write(b)
+	isNil(err, t)
+	equals(0, len(calls), t)
+
+	// crossing the limit calls it once.
+	_, err = l.Write(b)
+	isNil(err, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals([][2]int64{{60, 100}}, calls, t)
+
+	// and once more for the next file.
+	_, err = l.Write(b)
+	isNil(err, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals([][2]int64{{60, 100}, {60, 100}}, calls, t)
+}
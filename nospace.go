@@ -0,0 +1,13 @@
+// +build !plan9
+
+package lumberjack
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isNoSpace reports whether err was caused by the disk being full.
+func isNoSpace(err error) bool {
+	return errors.Is(err, syscall.ENOSPC)
+}
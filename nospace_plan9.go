@@ -0,0 +1,7 @@
+package lumberjack
+
+// isNoSpace reports whether err was caused by the disk being full, which
+// can't be told from the errors plan9 gives.
+func isNoSpace(err error) bool {
+	return false
+}
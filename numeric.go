@@ -0,0 +1,147 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNumericBackups is returned when NumericBackups is set along with an
+// option that keeps track of backups by name, which doesn't work when each
+// rotation renames them.
+var ErrNumericBackups = errors.New("option can't be used with NumericBackups")
+
+// numericErr returns an error wrapping ErrNumericBackups if NumericBackups is
+// set along with Uploader, WriteIndex, PruneExclude or PruneExcludeFunc.
+// Those all go by a backup's name, which with NumericBackups stays with its
+// place in the numbering rather than its contents.
+func (l *Logger) numericErr() error {
+	if !l.NumericBackups {
+		return nil
+	}
+	switch {
+	case l.Uploader != nil:
+		return fmt.Errorf("%w: Uploader", ErrNumericBackups)
+	case l.WriteIndex:
+		return fmt.Errorf("%w: WriteIndex", ErrNumericBackups)
+	case len(l.PruneExclude) > 0:
+		return fmt.Errorf("%w: PruneExclude", ErrNumericBackups)
+	case l.PruneExcludeFunc != nil:
+		return fmt.Errorf("%w: PruneExcludeFunc", ErrNumericBackups)
+	}
+	return nil
+}
+
+// numberedBackup reports whether filename is a backup named the way
+// NumericBackups names them from the log file base, returning its number and
+// whatever follows the number, such as ".gz".
+func numberedBackup(filename, base string) (n int, suffix string, ok bool) {
+	if !strings.HasPrefix(filename, base+".") {
+		return 0, "", false
+	}
+	rest := filename[len(base)+1:]
+	i := 0
+	for i < len(rest) && rest[i] >= '0' && rest[i] <= '9' {
+		i++
+	}
+	if i == 0 || rest[0] == '0' {
+		return 0, "", false
+	}
+	suffix = rest[i:]
+	if c := codecFor(suffix); suffix != "" && (c == nil || c.ext != suffix) {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(rest[:i])
+	if err != nil {
+		return 0, "", false
+	}
+	return n, suffix, true
+}
+
+// numberedBase returns the name, without its directory, that numbered
+// backups of the log file name are made from.  With CompressActive, that
+// doesn't include the ".gz" that goes after the number.
+func (l *Logger) numberedBase(name string) string {
+	base := filepath.Base(name)
+	if l.CompressActive {
+		base = strings.TrimSuffix(base, compressSuffix)
+	}
+	return base
+}
+
+// numberedBackupName returns the name that the log file name is moved to when
+// it is rotated with NumericBackups set.
+func (l *Logger) numberedBackupName(name string) string {
+	backup := filepath.Join(filepath.Dir(name), l.numberedBase(name)+".1")
+	if l.CompressActive {
+		backup += compressSuffix
+	}
+	return backup
+}
+
+// shiftBackups renumbers the numbered backups of the log file name, from the
+// highest number down, so that there's room for it to become backup 1.  It
+// waits for any cleanup under way, so that no backup is renamed while it's
+// being compressed.
+func (l *Logger) shiftBackups(name string) error {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+
+	dir := filepath.Dir(name)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("can't read log file directory: %w", err)
+	}
+	base := l.numberedBase(name)
+	type numbered struct {
+		n      int
+		suffix string
+	}
+	var backups []numbered
+	for _, f := range files {
+		if n, suffix, ok := numberedBackup(f.Name(), base); ok && !f.IsDir() {
+			backups = append(backups, numbered{n, suffix})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].n > backups[j].n })
+	for _, b := range backups {
+		from := filepath.Join(dir, base+"."+strconv.Itoa(b.n)+b.suffix)
+		to := filepath.Join(dir, base+"."+strconv.Itoa(b.n+1)+b.suffix)
+		if err := l.renameBackup(from, to); err != nil {
+			return fmt.Errorf("can't renumber backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// numberedLogFiles returns the numbered backups in files, with made up
+// timestamps that sort them by number, newest first, and otherwise come from
+// their modification times, so MaxAge goes by those.
+func (l *Logger) numberedLogFiles(files []os.FileInfo) []logInfo {
+	base := l.numberedBase(l.filename())
+	var backups []logInfo
+	nums := map[string]int{}
+	for _, f := range files {
+		if n, _, ok := numberedBackup(f.Name(), base); ok && !f.IsDir() {
+			backups = append(backups, logInfo{f.ModTime(), f})
+			nums[f.Name()] = n
+		}
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return nums[backups[i].Name()] < nums[backups[j].Name()]
+	})
+	// a compressed backup is newer than the one before it, so make the times
+	// go down with the numbers.
+	for i := 1; i < len(backups); i++ {
+		if !backups[i].timestamp.Before(backups[i-1].timestamp) {
+			backups[i].timestamp = backups[i-1].timestamp.Add(-time.Nanosecond)
+		}
+	}
+	return backups
+}
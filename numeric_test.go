@@ -0,0 +1,109 @@
+package lumberjack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNumericBackups(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNumericBackups", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	l := &Logger{
+		Filename:       filename,
+		NumericBackups: true,
+		MaxBackups:     2,
+		ManualCleanup:  true,
+	}
+	defer l.Close()
+
+	for _, s := range []string{"one", "two", "three"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+	_, err := l.Write([]byte("four"))
+	isNil(err, t)
+
+	existsWithContent(filename, []byte("four"), t)
+	existsWithContent(filename+".1", []byte("three"), t)
+	existsWithContent(filename+".2", []byte("two"), t)
+	existsWithContent(filename+".3", []byte("one"), t)
+
+	isNil(l.CleanNow(), t)
+	notExist(filename+".3", t)
+	fileCount(dir, 3, t)
+
+	// compressed backups keep their extension as they move up.
+	l.Compress = true
+	isNil(l.CleanNow(), t)
+	exists(filename+".1.gz", t)
+	exists(filename+".2.gz", t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	existsWithContent(filename+".1", []byte("four"), t)
+	exists(filename+".2.gz", t)
+	exists(filename+".3.gz", t)
+
+	isNil(l.CleanNow(), t)
+	exists(filename+".1.gz", t)
+	exists(filename+".2.gz", t)
+	notExist(filename+".3.gz", t)
+}
+
+func TestNumericBackupsConflicts(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestNumericBackupsConflicts", t)
+	defer os.RemoveAll(dir)
+
+	filename := logFile(dir)
+	for _, l := range []*Logger{
+		{Filename: filename, NumericBackups: true, Uploader: &fakeUploader{}},
+		{Filename: filename, NumericBackups: true, WriteIndex: true},
+		{Filename: filename, NumericBackups: true, PruneExclude: []string{"*.1"}},
+		{Filename: filename, NumericBackups: true, PruneExcludeFunc: func(string) bool { return true }},
+	} {
+		_, err := l.Write([]byte("boo!"))
+		assert(errors.Is(err, ErrNumericBackups), t, "expected ErrNumericBackups, got %v", err)
+		err = l.Preflight()
+		assert(errors.Is(err, ErrNumericBackups), t, "expected ErrNumericBackups, got %v", err)
+		isNil(l.Close(), t)
+	}
+	notExist(filename, t)
+
+	l := &Logger{Filename: filename, NumericBackups: true, ManualCleanup: true}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	isNil(l.Rotate(), t)
+	err = l.PinBackup(filepath.Base(filename) + ".1")
+	assert(errors.Is(err, ErrNumericBackups), t, "expected ErrNumericBackups, got %v", err)
+}
+
+func TestNumberedBackup(t *testing.T) {
+	tests := []struct {
+		name   string
+		n      int
+		suffix string
+		ok     bool
+	}{
+		{"foo.log.1", 1, "", true},
+		{"foo.log.12.gz", 12, ".gz", true},
+		{"foo.log.0", 0, "", false},
+		{"foo.log.", 0, "", false},
+		{"foo.log.1.txt", 0, "", false},
+		{"foo.log.x", 0, "", false},
+		{"bar.log.1", 0, "", false},
+	}
+	for _, tt := range tests {
+		n, suffix, ok := numberedBackup(tt.name, "foo.log")
+		equals(tt.ok, ok, t)
+		equals(tt.n, n, t)
+		equals(tt.suffix, suffix, t)
+	}
+}
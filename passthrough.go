@@ -0,0 +1,47 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// passthroughModes are the kinds of file that can't be rotated, which the
+// Logger just writes to.
+const passthroughModes = os.ModeDevice | os.ModeCharDevice | os.ModeNamedPipe
+
+// isPassthrough reports whether name is somewhere that can't be rotated,
+// such as a character device, a FIFO or /dev/stdout, as is common when a
+// container's log file is pointed at its output.
+func (l *Logger) isPassthrough(name string) bool {
+	switch name {
+	case "/dev/stdout", "/dev/stderr":
+		return true
+	}
+	info, err := l.stat(name)
+	return err == nil && info.Mode()&passthroughModes != 0
+}
+
+// passingThrough reports whether writes go, or will go once the log file is
+// opened, to a file that can't be rotated.
+func (l *Logger) passingThrough() bool {
+	if l.file != nil {
+		return l.passthrough
+	}
+	return l.isPassthrough(l.filename())
+}
+
+// openPassthrough opens name, which can't be rotated, for writing.  Nothing
+// is done to the file but writing to it: it isn't rotated, its size isn't
+// tracked and there are no backups to clean up.  Opening a FIFO waits until
+// something opens it for reading.
+func (l *Logger) openPassthrough(name string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return failed(ErrOpenFailed, fmt.Errorf("can't open log file: %w", err))
+	}
+	l.file = f
+	l.size = 0
+	l.unused = false
+	l.passthrough = true
+	return nil
+}
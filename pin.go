@@ -0,0 +1,93 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PinBackup pins the named backup, so that cleanup leaves it alone, as with
+// PruneExclude, until UnpinBackup is called, for keeping a backup that
+// matters to an ongoing investigation past MaxAge and MaxBackups.  name is
+// given as for OpenBackup.  The pin is recorded in the backup index, which
+// is written for it even if WriteIndex isn't set, so it survives restarts.
+// The error satisfies os.IsNotExist if there is no such backup, and wraps
+// ErrNumericBackups if NumericBackups is set.
+func (l *Logger) PinBackup(name string) error {
+	if l.NumericBackups {
+		return fmt.Errorf("%w: PinBackup", ErrNumericBackups)
+	}
+	return l.setPin(name, true)
+}
+
+// UnpinBackup releases a backup pinned by PinBackup, so that it is cleaned
+// up like any other.  Unpinning a backup that isn't pinned does nothing.
+func (l *Logger) UnpinBackup(name string) error {
+	return l.setPin(name, false)
+}
+
+// setPin pins or unpins the named backup.
+func (l *Logger) setPin(name string, pin bool) error {
+	l.mu.Lock()
+	paths, err := l.backupPaths()
+	index := l.indexName()
+	dir := l.dir()
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	base := trimCompressExt(filepath.Base(name))
+	found := false
+	for _, path := range paths {
+		if filepath.Base(path) == base {
+			found = true
+		}
+	}
+	if pin && !found {
+		return &os.PathError{Op: "pin", Path: filepath.Join(dir, name), Err: os.ErrNotExist}
+	}
+
+	// keep the mill from rewriting the index meanwhile.
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	idx, err := ReadIndex(index)
+	if os.IsNotExist(err) {
+		if !pin {
+			return nil
+		}
+		idx, err = &BackupIndex{Backups: []BackupRecord{}}, nil
+	}
+	if err != nil {
+		return err
+	}
+	pinned := idx.Pinned[:0]
+	for _, p := range idx.Pinned {
+		if p != base {
+			pinned = append(pinned, p)
+		}
+	}
+	if pin {
+		pinned = append(pinned, base)
+	}
+	idx.Pinned = pinned
+
+	b, err := json.MarshalIndent(idx, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(index, b, 0644)
+}
+
+// pins returns the names of the pinned backups, as they were rotated to.
+func (l *Logger) pins() map[string]bool {
+	idx, err := ReadIndex(l.indexName())
+	if err != nil {
+		return nil
+	}
+	pins := make(map[string]bool, len(idx.Pinned))
+	for _, p := range idx.Pinned {
+		pins[p] = true
+	}
+	return pins
+}
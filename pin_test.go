@@ -0,0 +1,64 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinBackup(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestPinBackup", t)
+	defer os.RemoveAll(dir)
+
+	var names []string
+	for i := 0; i < 3; i++ {
+		name := backupFile(dir)
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		names = append(names, name)
+		newFakeTime()
+	}
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxBackups:    1,
+		Compress:      true,
+		WriteIndex:    true,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	err := l.PinBackup("foobar-nope.log")
+	assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+	isNil(l.PinBackup(filepath.Base(names[0])), t)
+
+	// the pinned backup is neither removed nor compressed, and doesn't count
+	// toward MaxBackups.
+	isNil(l.CleanNow(), t)
+	exists(names[0], t)
+	notExist(names[1], t)
+	exists(names[2]+compressSuffix, t)
+	idx, err := ReadIndex(logFile(dir) + indexSuffix)
+	isNil(err, t)
+	equals([]string{filepath.Base(names[0])}, idx.Pinned, t)
+	equals(2, len(idx.Backups), t)
+
+	// a new Logger sees the pin too.
+	l2 := &Logger{
+		Filename:      logFile(dir),
+		MaxBackups:    1,
+		ManualCleanup: true,
+	}
+	defer l2.Close()
+	isNil(l2.CleanNow(), t)
+	exists(names[0], t)
+
+	isNil(l.UnpinBackup(filepath.Base(names[0])), t)
+	isNil(l.UnpinBackup(filepath.Base(names[0])), t)
+	isNil(l.CleanNow(), t)
+	notExist(names[0], t)
+	idx, err = ReadIndex(logFile(dir) + indexSuffix)
+	isNil(err, t)
+	equals(0, len(idx.Pinned), t)
+}
@@ -0,0 +1,112 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+var (
+	// ErrFilenameIsDir is returned by Preflight when Filename is a directory.
+	ErrFilenameIsDir = errors.New("log file name is a directory")
+
+	// ErrDirNotWritable is returned by Preflight when the log file's
+	// directory can't be created or written to.
+	ErrDirNotWritable = errors.New("log directory is not writable")
+
+	// ErrNoSpace is returned by Preflight when the disk holding the log file
+	// has less than MaxSize free.
+	ErrNoSpace = errors.New("not enough free space for log file")
+
+	// ErrBackupName is returned by Preflight when Filename looks like a
+	// backup, so another Logger in the same directory could remove it.
+	ErrBackupName = errors.New("log file name looks like a backup")
+
+	// ErrInvalidName is returned by Preflight when Filename, or the names of
+	// the backups that would be made from it, aren't allowed on this
+	// operating system, because of a reserved name or character on Windows,
+	// say, or being too long.
+	ErrInvalidName = errors.New("log file name is not valid")
+)
+
+// Preflight checks that the Logger can work with its configuration, so that
+// problems show up at startup rather than at the first Write.  It checks that
+// Filename and the names of its backups are allowed on this operating
+// system, that Filename is not a directory, that its directory exists or can
+// be created and is writable, that the disk has at least MaxSize free (where
+// the platform can tell), and that Filename doesn't look like one of the
+// backups that another Logger in the same directory would create.  The
+// returned error wraps ErrInvalidName, ErrFilenameIsDir, ErrDirNotWritable,
+// ErrNoSpace or ErrBackupName accordingly, or ErrUnsetEnv if Filename can't
+// be expanded.  If RotateDaily is set, it also checks that TimeZone can be
+// loaded, and it returns an error wrapping ErrNumericBackups if
+// NumericBackups is set with an option that can't be used with it.  Only the
+// first two checks apply when Filename is a device or FIFO that is written
+// to without rotation.  Preflight doesn't open the log file.
+func (l *Logger) Preflight() error {
+	if err := l.filenameErr(); err != nil {
+		return err
+	}
+	if err := l.dailyErr(); err != nil {
+		return err
+	}
+	if err := l.numericErr(); err != nil {
+		return err
+	}
+	name := l.primaryFilename()
+
+	if err := validateName(name, runtime.GOOS, l.backupNameExtra()); err != nil {
+		return err
+	}
+	if info, err := l.stat(name); err == nil && info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrFilenameIsDir, name)
+	}
+	if l.isPassthrough(name) {
+		return nil
+	}
+
+	dir := filepath.Dir(name)
+	if err := probeDir(dir); err != nil {
+		return err
+	}
+
+	// not knowing the free space isn't a reason to fail.
+	if free, _, err := diskSpace(dir); err == nil && free < uint64(l.max()) {
+		return fmt.Errorf("%w: %d bytes free in %s, need %d", ErrNoSpace, free, dir, l.max())
+	}
+
+	if looksLikeBackup(filepath.Base(name)) {
+		return fmt.Errorf("%w: %s", ErrBackupName, name)
+	}
+	return nil
+}
+
+// looksLikeBackup reports whether name is in the form of a backup's name,
+// with a timestamp before the extension.
+func looksLikeBackup(name string) bool {
+	stem := name[:len(name)-len(filepath.Ext(name))]
+	i := len(stem) - len(backupTimeFormat) - 1
+	if i < 0 || stem[i] != '-' {
+		return false
+	}
+	_, err := time.Parse(backupTimeFormat, stem[i+1:])
+	return err == nil
+}
+
+// probeDir checks that a file can be created in dir, creating dir if
+// necessary, returning an error wrapping ErrDirNotWritable if not.
+func probeDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("%w: %v", ErrDirNotWritable, err)
+	}
+	f, err := os.CreateTemp(dir, ".lumberjack-preflight-*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDirNotWritable, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
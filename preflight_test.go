@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestPreflight", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: filepath.Join(dir, "sub", "foobar.log"), MaxSize: 10}
+	isNil(l.Preflight(), t)
+	// the directory is created, but not the file.
+	exists(filepath.Join(dir, "sub"), t)
+	fileCount(filepath.Join(dir, "sub"), 0, t)
+
+	l = &Logger{Filename: dir}
+	err := l.Preflight()
+	assert(errors.Is(err, ErrFilenameIsDir), t, "expected ErrFilenameIsDir, got %v", err)
+
+	blocker := filepath.Join(dir, "blocker")
+	err = ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+	l = &Logger{Filename: filepath.Join(blocker, "foobar.log")}
+	err = l.Preflight()
+	assert(errors.Is(err, ErrDirNotWritable), t, "expected ErrDirNotWritable, got %v", err)
+
+	// a petabyte ought to be more than any test machine has free.
+	megabyte = 1024 * 1024
+	l = &Logger{Filename: logFile(dir), MaxSize: 1 << 30}
+	if _, _, err := diskSpace(dir); err == nil {
+		err = l.Preflight()
+		assert(errors.Is(err, ErrNoSpace), t, "expected ErrNoSpace, got %v", err)
+	}
+	megabyte = 1
+
+	newFakeTime()
+	l = &Logger{Filename: backupFile(dir), MaxSize: 10}
+	err = l.Preflight()
+	assert(errors.Is(err, ErrBackupName), t, "expected ErrBackupName, got %v", err)
+
+	// RotateDaily needs a time zone it can load.
+	l = &Logger{Filename: logFile(dir), RotateDaily: true, TimeZone: "Not/AZone"}
+	notNil(l.Preflight(), t)
+	l.RotateDaily = false
+	isNil(l.Preflight(), t)
+}
+
+func TestValidateName(t *testing.T) {
+	long := strings.Repeat("a", 250)
+	tests := []struct {
+		name  string
+		goos  string
+		valid bool
+	}{
+		{"/var/log/app.log", "linux", true},
+		{"/var/log/con.log", "linux", true},
+		{"/var/log/app\x00.log", "linux", false},
+		{"/var/log/" + long + ".log", "linux", false},
+		{"/" + strings.Repeat("a/", 2100) + "app.log", "linux", false},
+		{`C:\logs\app.log`, "windows", true},
+		{`C:/logs/app.log`, "windows", true},
+		{`\\server\share\app.log`, "windows", true},
+		{`C:\logs\..\app.log`, "windows", true},
+		{`C:\logs\CON`, "windows", false},
+		{`C:\logs\nul.log`, "windows", false},
+		{`C:\com1\app.log`, "windows", false},
+		{`C:\logs\app.log.`, "windows", false},
+		{`C:\logs \app.log`, "windows", false},
+		{`C:\logs\app?.log`, "windows", false},
+		{`C:\logs\app:1.log`, "windows", false},
+		{`C:\logs\app` + "\t" + `.log`, "windows", false},
+		{`C:\` + strings.Repeat(`a\`, 120) + "app.log", "windows", false},
+		{`\\?\C:\` + strings.Repeat(`a\`, 120) + "app.log", "windows", true},
+	}
+	for _, tt := range tests {
+		err := validateName(tt.name, tt.goos, 24)
+		if tt.valid {
+			assert(err == nil, t, "expected %q to be valid on %s, got %v", tt.name, tt.goos, err)
+		} else {
+			assert(errors.Is(err, ErrInvalidName), t, "expected %q to be invalid on %s, got %v", tt.name, tt.goos, err)
+		}
+	}
+
+	// the name is too long once a backup's timestamp is added to it.
+	l := &Logger{Filename: filepath.Join(os.TempDir(), long+".log")}
+	err := l.Preflight()
+	assert(errors.Is(err, ErrInvalidName), t, "expected ErrInvalidName, got %v", err)
+}
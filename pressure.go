@@ -0,0 +1,220 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// DiskPressure configures a background check of how full the disk that holds
+// the log file is.  Each check that finds it fuller than Threshold takes the
+// next of these steps, and the last is repeated for as long as the disk stays
+// too full:
+//
+//  1. compress every uncompressed backup, whether or not Compress is set;
+//  2. remove backups, oldest first, until the disk is no longer too full,
+//     keeping the PurgeKeep most recent;
+//  3. rotate the log file and remove the backup, losing what was in it.
+//
+// Each step is reported by an EventPressure event, as well as the events for
+// what it does.  Once a check finds the disk below Threshold, the next time
+// it's too full starts from the first step again.  Nothing is done while
+// maintenance is paused.
+type DiskPressure struct {
+	// Threshold is how full the disk may get, as a fraction such as 0.9,
+	// before cleanup starts.
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+
+	// Interval is how often the disk is checked.  The default is a minute.
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// pressureSpace is diskSpace, as a variable so tests can fake a full disk.
+var pressureSpace = diskSpace
+
+// usage returns the fraction of the disk holding dir that is in use.
+func usage(dir string) (float64, error) {
+	free, total, err := pressureSpace(dir)
+	if err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, errors.New("disk has no size")
+	}
+	return 1 - float64(free)/float64(total), nil
+}
+
+// startPressure starts the DiskPressure check, if it's wanted and not already
+// running.  The Logger's lock must be held.
+func (l *Logger) startPressure() {
+	if l.DiskPressure == nil || l.pressureStop != nil {
+		return
+	}
+	interval := l.DiskPressure.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	l.pressureStop = make(chan struct{})
+	l.pressureDone = make(chan struct{})
+	go l.pressureRun(interval, l.pressureStop, l.pressureDone)
+}
+
+// stopPressure stops the DiskPressure check, if it is running, and waits for
+// a check that's under way to finish, so that nothing is done to the log
+// files once it returns.  A check waiting for the lock that must be held to
+// call this gives up instead.
+func (l *Logger) stopPressure() {
+	if l.pressureStop != nil {
+		close(l.pressureStop)
+		<-l.pressureDone
+		l.pressureStop, l.pressureDone = nil, nil
+	}
+}
+
+// pressureRun checks the disk every interval until stop is closed, and then
+// closes done.
+func (l *Logger) pressureRun(interval time.Duration, stop, done chan struct{}) {
+	defer close(done)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			l.checkPressure(stop)
+		}
+	}
+}
+
+// checkPressure checks how full the disk is, and takes the next step to
+// relieve it if it is too full.
+func (l *Logger) checkPressure(stop chan struct{}) {
+	if l.maintenancePaused() {
+		return
+	}
+	dir := l.dir()
+	used, err := usage(dir)
+	if err != nil || used <= l.DiskPressure.Threshold {
+		l.pressureStep = 0
+		return
+	}
+	if l.pressureStep < 3 {
+		l.pressureStep++
+	}
+	cause := fmt.Errorf("disk holding %s is %.0f%% full, over the threshold of %.0f%%",
+		dir, used*100, l.DiskPressure.Threshold*100)
+	l.emit(Event{Type: EventPressure, Filename: l.filename(), Step: l.pressureStep, Err: cause})
+
+	var errs []error
+	switch l.pressureStep {
+	case 1:
+		errs = l.pressureCompress(dir)
+	case 2:
+		errs = l.pressureRemove(dir)
+	case 3:
+		errs = l.pressureTruncate(stop)
+	}
+	if err := errors.Join(errs...); err != nil {
+		l.setMillErr(err)
+	}
+}
+
+// pressureCompress compresses every uncompressed backup in dir.
+func (l *Logger) pressureCompress(dir string) []error {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	if l.maintenancePaused() {
+		return nil
+	}
+	c, err := l.codec()
+	if err != nil {
+		return []error{err}
+	}
+	files, err := l.oldLogFilesIn(dir)
+	if err != nil {
+		return []error{err}
+	}
+	files = l.prunable(files)
+	var errs []error
+	for _, f := range files {
+		if !isCompressed(f.Name()) {
+			if err := l.compressBackup(dir, f, c); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// pressureRemove removes backups, oldest first, until the disk holding dir
+// is no longer too full, keeping the PurgeKeep most recent.
+func (l *Logger) pressureRemove(dir string) []error {
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	if l.maintenancePaused() {
+		return nil
+	}
+	files, err := l.oldLogFilesIn(dir)
+	if err != nil {
+		return []error{err}
+	}
+	files = l.prunable(files)
+	var errs []error
+	for i := len(files) - 1; i >= l.PurgeKeep; i-- {
+		fn := filepath.Join(dir, files[i].Name())
+		err := failed(ErrRemoveFailed, removeFile(fn))
+		if err != nil {
+			errs = append(errs, err)
+		}
+		l.emit(Event{Type: EventRemove, Filename: fn, Size: files[i].Size(), Err: err})
+		if used, err := usage(dir); err == nil && used <= l.DiskPressure.Threshold {
+			break
+		}
+	}
+	return errs
+}
+
+// pressureTruncate rotates the log file and removes the backup that makes,
+// unless stop is closed before the lock is held.
+func (l *Logger) pressureTruncate(stop chan struct{}) []error {
+	// Close holds the lock while it waits for the check to stop, so don't
+	// wait for it once stop is closed.
+	for !l.mu.TryLock() {
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(time.Millisecond):
+		}
+	}
+	defer l.mu.Unlock()
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+	if l.file == nil || l.passthrough || l.maintenancePaused() {
+		return nil
+	}
+	if err := l.rotate(RotatePressure); err != nil {
+		return []error{err}
+	}
+	l.waitHandoff()
+	l.millMu.Lock()
+	defer l.millMu.Unlock()
+	if l.maintenancePaused() {
+		return nil
+	}
+	files, err := l.oldLogFiles()
+	if err != nil || len(files) == 0 {
+		return []error{err}
+	}
+	if l.excluded(files[0].Name(), l.pins()) {
+		return nil
+	}
+	fn := filepath.Join(l.dir(), files[0].Name())
+	err = failed(ErrRemoveFailed, removeFile(fn))
+	l.emit(Event{Type: EventRemove, Filename: fn, Size: files[0].Size(), Err: err})
+	return []error{err}
+}
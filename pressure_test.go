@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDiskPressure(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestDiskPressure", t)
+	defer os.RemoveAll(dir)
+
+	var free uint64 = 5
+	pressureSpace = func(string) (uint64, uint64, error) { return free, 100, nil }
+	defer func() { pressureSpace = diskSpace }()
+
+	filename := logFile(dir)
+	var steps []int
+	l := &Logger{
+		Filename:      filename,
+		ManualCleanup: true,
+		PurgeKeep:     1,
+		DiskPressure:  &DiskPressure{Threshold: 0.9},
+		OnEvent: func(e Event) {
+			if e.Type == EventPressure {
+				steps = append(steps, e.Step)
+			}
+		},
+	}
+	defer l.Close()
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		backups = append(backups, backupFile(dir))
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	stop := make(chan struct{})
+
+	// first, the backups are compressed.
+	l.checkPressure(stop)
+	for _, b := range backups {
+		notExist(b, t)
+		exists(b+compressSuffix, t)
+	}
+
+	// then they are removed, oldest first, but the newest is kept.
+	l.checkPressure(stop)
+	notExist(backups[0]+compressSuffix, t)
+	notExist(backups[1]+compressSuffix, t)
+	exists(backups[2]+compressSuffix, t)
+
+	// then the log file is emptied.
+	newFakeTime()
+	l.checkPressure(stop)
+	existsWithContent(filename, []byte{}, t)
+	exists(backups[2]+compressSuffix, t)
+	fileCount(dir, 2, t)
+	equals([]int{1, 2, 3}, steps, t)
+
+	// once there's room, it starts again from the first step.
+	free = 50
+	l.checkPressure(stop)
+	free = 5
+	l.checkPressure(stop)
+	equals([]int{1, 2, 3, 1}, steps, t)
+
+	// removal stops as soon as there's room.
+	isNil(ioutil.WriteFile(backups[0], []byte("old"), 0644), t)
+	isNil(ioutil.WriteFile(backups[1], []byte("old"), 0644), t)
+	pressureSpace = func(string) (uint64, uint64, error) {
+		free += 6
+		return free, 100, nil
+	}
+	free = 0
+	l.pressureStep = 1
+	l.checkPressure(stop)
+	notExist(backups[0], t)
+	exists(backups[1], t)
+}
+
+func TestDiskPressurePaused(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestDiskPressurePaused", t)
+	defer os.RemoveAll(dir)
+
+	pressureSpace = func(string) (uint64, uint64, error) { return 5, 100, nil }
+	defer func() { pressureSpace = diskSpace }()
+
+	filename := logFile(dir)
+	var steps []int
+	l := &Logger{
+		Filename:      filename,
+		ManualCleanup: true,
+		DiskPressure:  &DiskPressure{Threshold: 0.9},
+		OnEvent: func(e Event) {
+			if e.Type == EventPressure {
+				steps = append(steps, e.Step)
+			}
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+	_, err = l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	// while maintenance is paused, none of the steps are taken.
+	l.PauseMaintenance()
+	stop := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		l.checkPressure(stop)
+	}
+	existsWithContent(backup, []byte("boo!"), t)
+	existsWithContent(filename, []byte("boo!"), t)
+	equals(0, len(steps), t)
+
+	// and they start from the first once it is resumed.
+	l.ResumeMaintenance()
+	l.checkPressure(stop)
+	notExist(backup, t)
+	exists(backup+compressSuffix, t)
+	equals([]int{1}, steps, t)
+}
+
+func TestDiskPressureStopsOnClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestDiskPressureStopsOnClose", t)
+	defer os.RemoveAll(dir)
+
+	pressureSpace = func(string) (uint64, uint64, error) { return 5, 100, nil }
+	defer func() { pressureSpace = diskSpace }()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	l := &Logger{
+		Filename:      logFile(dir),
+		ManualCleanup: true,
+		PurgeKeep:     1,
+		DiskPressure:  &DiskPressure{Threshold: 0.9, Interval: time.Millisecond},
+		OnEvent: func(e Event) {
+			if e.Type == EventPressure && e.Step == 1 {
+				close(started)
+				<-release
+			}
+		},
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	backup := backupFile(dir)
+
+	// Close waits for the check that's under way, so the backup has been
+	// compressed by the time it returns.
+	<-started
+	closed := make(chan error)
+	go func() { closed <- l.Close() }()
+	select {
+	case <-closed:
+		t.Fatal("Close returned while the check was under way")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	isNil(<-closed, t)
+	notExist(backup, t)
+	exists(backup+compressSuffix, t)
+}
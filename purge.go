@@ -0,0 +1,25 @@
+package lumberjack
+
+import (
+	"path/filepath"
+)
+
+// purgeForSpace removes the oldest backup if PurgeOnNoSpace is set and err
+// shows that the disk is full.  It reports whether a backup was removed, in
+// which case the failed operation is worth retrying.
+func (l *Logger) purgeForSpace(err error) bool {
+	if !l.PurgeOnNoSpace || !isNoSpace(err) {
+		return false
+	}
+	files, ferr := l.oldLogFiles()
+	files = l.prunable(files)
+	if ferr != nil || len(files) <= l.PurgeKeep {
+		return false
+	}
+	oldest := filepath.Join(l.dir(), files[len(files)-1].Name())
+	if removeFile(oldest) != nil {
+		return false
+	}
+	l.emit(Event{Type: EventPurge, Filename: oldest, Err: err})
+	return true
+}
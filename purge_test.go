@@ -0,0 +1,70 @@
+// +build !plan9
+
+package lumberjack
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestPurgeForSpace(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestPurgeForSpace", t)
+	defer os.RemoveAll(dir)
+
+	var backups []string
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		name := backupFile(dir)
+		err := ioutil.WriteFile(name, []byte("data"), 0644)
+		isNil(err, t)
+		backups = append(backups, name)
+	}
+
+	var events []Event
+	l := &Logger{
+		Filename:       logFile(dir),
+		PurgeOnNoSpace: true,
+		PurgeKeep:      1,
+		OnEvent:        func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	full := &os.PathError{Op: "write", Path: logFile(dir), Err: syscall.ENOSPC}
+
+	assert(!l.purgeForSpace(errors.New("boom")), t, "purged for an unrelated error")
+	fileCount(dir, 3, t)
+
+	assert(l.purgeForSpace(full), t, "expected a backup to be purged")
+	notExist(backups[0], t)
+	exists(backups[1], t)
+
+	assert(l.purgeForSpace(full), t, "expected a backup to be purged")
+	notExist(backups[1], t)
+
+	// PurgeKeep protects the last backup.
+	assert(!l.purgeForSpace(full), t, "purged a protected backup")
+	exists(backups[2], t)
+
+	equals(2, len(events), t)
+	equals(EventPurge, events[0].Type, t)
+	equals(backups[0], events[0].Filename, t)
+}
+
+func TestPurgeForSpaceDisabled(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestPurgeForSpaceDisabled", t)
+	defer os.RemoveAll(dir)
+
+	newFakeTime()
+	err := ioutil.WriteFile(backupFile(dir), []byte("data"), 0644)
+	isNil(err, t)
+
+	l := &Logger{Filename: logFile(dir)}
+	full := &os.PathError{Op: "write", Path: logFile(dir), Err: syscall.ENOSPC}
+	assert(!l.purgeForSpace(full), t, "purged without PurgeOnNoSpace")
+	fileCount(dir, 1, t)
+}
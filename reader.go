@@ -0,0 +1,278 @@
+package lumberjack
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// OpenHistory returns a reader over all of the retained log data: each backup
+// in turn from oldest to newest, followed by the current log file.  Compressed
+// backups are decompressed as they are read, and a backup that is part way
+// through being compressed is only read once.  All of the files are opened
+// before OpenHistory returns, so rotations and cleanup that happen while the
+// reader is in use don't change what it reads, and the current log file is
+// read as for OpenCurrent.  Closing the reader closes all of the files.
+func (l *Logger) OpenHistory() (io.ReadCloser, error) {
+	l.flushBatch()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	paths, err := l.backupPaths()
+	if err != nil {
+		return nil, err
+	}
+	mr := &multiReadCloser{}
+	for i := len(paths) - 1; i >= 0; i-- {
+		err := mr.add(openRotated(paths[i]))
+		// the mill may have removed the file since we listed it.
+		if err != nil && !os.IsNotExist(err) {
+			mr.Close()
+			return nil, err
+		}
+	}
+	if err := l.addCurrent(mr); err != nil {
+		mr.Close()
+		return nil, err
+	}
+	return mr, nil
+}
+
+// ReadRange is like OpenHistory, but only reads the files that hold log
+// data written between since and until, going by the times in the backups'
+// names: a backup holds what was written from when the backup before it was
+// rotated until it was rotated itself, and the current log file what was
+// written since the newest backup.  Whole files are read, so the data will
+// usually begin before since and end after until.  A zero since or until
+// leaves that end of the range open.
+func (l *Logger) ReadRange(since, until time.Time) (io.ReadCloser, error) {
+	l.flushBatch()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	mr := &multiReadCloser{}
+	seen := map[string]bool{}
+	// the time the file being looked at was started, as near as is known.
+	var start time.Time
+	for i := len(files) - 1; i >= 0; i-- {
+		base := trimCompressExt(files[i].Name())
+		if seen[base] {
+			continue
+		}
+		seen[base] = true
+		end := files[i].timestamp
+		if (since.IsZero() || !end.Before(since)) && (until.IsZero() || start.Before(until)) {
+			err := mr.add(openRotated(filepath.Join(l.dir(), base)))
+			// the mill may have removed the file since we listed it.
+			if err != nil && !os.IsNotExist(err) {
+				mr.Close()
+				return nil, err
+			}
+		}
+		start = end
+	}
+	if until.IsZero() || start.Before(until) {
+		if err := l.addCurrent(mr); err != nil {
+			mr.Close()
+			return nil, err
+		}
+	}
+	return mr, nil
+}
+
+// addCurrent adds the current log file to the end of mr, opened as for
+// OpenCurrent, unless there is no log file or it can't be read back.  It
+// must be called with l.mu held.
+func (l *Logger) addCurrent(mr *multiReadCloser) error {
+	if l.passingThrough() {
+		return nil
+	}
+	if err := mr.add(l.openCurrent()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// OpenCurrent returns a reader of what has been written to the log file so
+// far, for a diagnostics endpoint that serves the latest logs, say.  It reads
+// through a handle of its own, and only as far as the file went when
+// OpenCurrent was called, so it neither sees nor gets in the way of later
+// writes, and it keeps reading the same data if the file is rotated
+// meanwhile.  With CompressActive, what has been written is flushed through
+// to the file first and decompressed as it is read.  The error satisfies
+// os.IsNotExist if there is no log file yet.
+func (l *Logger) OpenCurrent() (io.ReadCloser, error) {
+	l.flushBatch()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.passingThrough() {
+		return nil, fmt.Errorf("log file %s can't be read back", l.filename())
+	}
+	return l.openCurrent()
+}
+
+// openCurrent does the work of OpenCurrent, for the other readers of the log
+// to share.  It must be called with l.mu held.
+func (l *Logger) openCurrent() (io.ReadCloser, error) {
+	l.waitHandoff()
+	name := l.filename()
+	if err := l.flushGzip(); err != nil {
+		return nil, fmt.Errorf("can't flush log file: %w", err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &currentReader{Reader: io.LimitReader(f, info.Size()), f: f}
+	if l.CompressActive && info.Size() > 0 {
+		gz, err := gzip.NewReader(r.Reader)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("can't read compressed log file %s: %w", name, err)
+		}
+		r.Reader = gz
+	}
+	return r, nil
+}
+
+// currentReader reads a snapshot of the log file, closing the file when it
+// is closed.
+type currentReader struct {
+	io.Reader
+	f *os.File
+}
+
+// Read implements io.Reader.  A gzip stream that hasn't been finished yet
+// ends where it was last flushed, rather than being an error.
+func (r *currentReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (r *currentReader) Close() error {
+	return r.f.Close()
+}
+
+// OpenBackup returns a reader of the named backup, decompressing it if it is
+// compressed.  name is the backup's file name, without its directory, and
+// may be given with or without the suffix that compression adds, so that
+// the name a backup was rotated to still finds it once it has been
+// compressed.  The error satisfies os.IsNotExist if there is no such backup.
+func (l *Logger) OpenBackup(name string) (io.ReadCloser, error) {
+	l.mu.Lock()
+	paths, err := l.backupPaths()
+	dir := l.dir()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	base := trimCompressExt(name)
+	for _, path := range paths {
+		if filepath.Base(path) == base {
+			return openRotated(path)
+		}
+	}
+	return nil, &os.PathError{Op: "open", Path: filepath.Join(dir, name), Err: os.ErrNotExist}
+}
+
+// OpenBackupAt is like OpenBackup, but opens the i'th most recent backup,
+// where 0 is the newest.
+func (l *Logger) OpenBackupAt(i int) (io.ReadCloser, error) {
+	l.mu.Lock()
+	paths, err := l.backupPaths()
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= len(paths) {
+		return nil, fmt.Errorf("no backup %d, there are %d", i, len(paths))
+	}
+	return openRotated(paths[i])
+}
+
+// backupPaths returns the paths of the backups, newest first, as they were
+// named when they were rotated, without any compression suffix.  A backup
+// that is part way through being compressed is only listed once.
+func (l *Logger) backupPaths() ([]string, error) {
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	seen := map[string]bool{}
+	for _, f := range files {
+		base := trimCompressExt(f.Name())
+		if !seen[base] {
+			seen[base] = true
+			paths = append(paths, filepath.Join(l.dir(), base))
+		}
+	}
+	return paths, nil
+}
+
+// openRotated opens the backup that was rotated to path, decompressing it if
+// it has since been compressed.  While a backup is being compressed, only the
+// uncompressed file is complete, and once that's gone, the compressed one is.
+func openRotated(path string) (io.ReadCloser, error) {
+	r, err := openBackup(path)
+	if os.IsNotExist(err) {
+		if cpath := compressedVersion(path); cpath != "" {
+			return openBackup(cpath)
+		}
+	}
+	return r, err
+}
+
+// multiReadCloser reads from a series of files one after another.
+type multiReadCloser struct {
+	readers []io.Reader
+	closers []io.Closer
+	r       io.Reader
+}
+
+// add adds r, as returned by openBackup or openRotated, to the end of the
+// series, passing on err if it couldn't be opened.
+func (m *multiReadCloser) add(r io.ReadCloser, err error) error {
+	if err != nil {
+		return err
+	}
+	m.closers = append(m.closers, r)
+	m.readers = append(m.readers, r)
+	return nil
+}
+
+// Read implements io.Reader.
+func (m *multiReadCloser) Read(p []byte) (int, error) {
+	if m.r == nil {
+		m.r = io.MultiReader(m.readers...)
+	}
+	return m.r.Read(p)
+}
+
+// Close implements io.Closer, closing all of the files.
+func (m *multiReadCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if errClose := c.Close(); err == nil && errClose != nil {
+			err = errClose
+		}
+	}
+	return err
+}
@@ -0,0 +1,230 @@
+package lumberjack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenHistory(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestOpenHistory", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		ReopenOnWrite: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// compress the next backup to check it gets decompressed, once the mill
+	// has finished with the first.
+	isNil(l.Close(), t)
+	l.Compress = true
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(300 * time.Millisecond)
+	exists(backupFile(dir)+compressSuffix, t)
+
+	_, err = l.Write([]byte("three\n"))
+	isNil(err, t)
+
+	r, err := l.OpenHistory()
+	isNil(err, t)
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("one\ntwo\nthree\n", string(b), t)
+}
+
+func TestReadRange(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestReadRange", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  10,
+	}
+	defer l.Close()
+
+	var rotated []time.Time
+	for _, s := range []string{"one\n", "two\n", "three\n"} {
+		_, err := l.Write([]byte(s))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		rotated = append(rotated, fakeCurrentTime)
+	}
+	_, err := l.Write([]byte("four\n"))
+	isNil(err, t)
+
+	tests := []struct {
+		since, until time.Time
+		want         string
+	}{
+		{time.Time{}, time.Time{}, "one\ntwo\nthree\nfour\n"},
+		{rotated[0].Add(time.Hour), rotated[1].Add(-time.Hour), "two\n"},
+		{rotated[0].Add(time.Hour), rotated[1].Add(time.Hour), "two\nthree\n"},
+		{time.Time{}, rotated[0].Add(-time.Hour), "one\n"},
+		{rotated[2].Add(time.Hour), time.Time{}, "four\n"},
+		{rotated[1].Add(-time.Hour), rotated[1].Add(-time.Hour), "two\n"},
+	}
+	for i, tt := range tests {
+		r, err := l.ReadRange(tt.since, tt.until)
+		isNil(err, t)
+		b, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		if string(b) != tt.want {
+			t.Errorf("%d: got %q, want %q", i, b, tt.want)
+		}
+	}
+}
+
+func TestOpenBackup(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestOpenBackup", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("one\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	first := filepath.Base(backupFile(dir))
+
+	_, err = l.Write([]byte("two\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// the first backup is compressed, but can still be found by the name it
+	// was rotated to.
+	err = l.compressLogFile(filepath.Join(dir, first), filepath.Join(dir, first)+compressSuffix, codecs["gzip"], false)
+	isNil(err, t)
+
+	readBackup := func(r io.ReadCloser, err error) string {
+		isNilUp(err, t, 1)
+		b, err := ioutil.ReadAll(r)
+		isNilUp(err, t, 1)
+		isNilUp(r.Close(), t, 1)
+		return string(b)
+	}
+	equals("one\n", readBackup(l.OpenBackup(first)), t)
+	equals("one\n", readBackup(l.OpenBackup(first+compressSuffix)), t)
+	equals("two\n", readBackup(l.OpenBackupAt(0)), t)
+	equals("one\n", readBackup(l.OpenBackupAt(1)), t)
+
+	_, err = l.OpenBackupAt(2)
+	notNil(err, t)
+	_, err = l.OpenBackup("nothere.log")
+	assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+}
+
+func TestOpenHistoryMixedCompression(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestOpenHistoryMixedCompression", t)
+	defer os.RemoveAll(dir)
+
+	// one backup in each format, and one that is part way through being
+	// compressed.
+	for i, ext := range []string{compressSuffix, ".zip", ""} {
+		newFakeTime()
+		name := backupFile(dir)
+		content := []byte(fmt.Sprintf("%d\n", i))
+		isNil(ioutil.WriteFile(name, content, 0644), t)
+		if ext != "" {
+			err := (&Logger{}).compressLogFile(name, name+ext, codecFor(name+ext), false)
+			isNil(err, t)
+		} else {
+			isNil(ioutil.WriteFile(name+compressSuffix, []byte("partial"), 0644), t)
+		}
+	}
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		ManualCleanup: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("3\n"))
+	isNil(err, t)
+
+	r, err := l.OpenHistory()
+	isNil(err, t)
+	b, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	isNil(r.Close(), t)
+	equals("0\n1\n2\n3\n", string(b), t)
+
+	lines, err := l.Tail(4)
+	isNil(err, t)
+	equals("0 1 2 3", string(bytes.Join(lines, []byte(" "))), t)
+}
+
+func TestOpenCurrent(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestOpenCurrent", t)
+	defer os.RemoveAll(dir)
+
+	for _, active := range []bool{false, true} {
+		l := &Logger{
+			Filename:       logFile(dir),
+			MaxSize:        1000,
+			ManualCleanup:  true,
+			CompressActive: active,
+		}
+
+		_, err := l.OpenCurrent()
+		assert(os.IsNotExist(err), t, "expected a not exist error, got %v", err)
+
+		_, err = l.Write([]byte("one\n"))
+		isNil(err, t)
+		r, err := l.OpenCurrent()
+		isNil(err, t)
+
+		// later writes and rotation don't change what the reader sees.
+		_, err = l.Write([]byte("two\n"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+
+		b, err := ioutil.ReadAll(r)
+		isNil(err, t)
+		isNil(r.Close(), t)
+		equals("one\n", string(b), t)
+
+		isNil(l.Close(), t)
+		isNil(os.RemoveAll(dir), t)
+		isNil(os.MkdirAll(dir, 0700), t)
+	}
+}
@@ -0,0 +1,33 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// makeReadOnly removes all write permission from the finished backup name if
+// ReadOnlyBackups is set.
+func (l *Logger) makeReadOnly(name string) error {
+	if !l.ReadOnlyBackups {
+		return nil
+	}
+	info, err := l.stat(name)
+	if err != nil {
+		return fmt.Errorf("can't make backup read-only: %w", err)
+	}
+	if err := os.Chmod(name, info.Mode().Perm()&^0222); err != nil {
+		return fmt.Errorf("can't make backup read-only: %w", err)
+	}
+	return nil
+}
+
+// removeFile removes name, even if it has been made read-only, which on
+// Windows would otherwise prevent it.
+func removeFile(name string) error {
+	err := os.Remove(name)
+	if err != nil && runtime.GOOS == "windows" && os.Chmod(name, 0600) == nil {
+		err = os.Remove(name)
+	}
+	return err
+}
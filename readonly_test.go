@@ -0,0 +1,75 @@
+package lumberjack
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyBackups(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestReadOnlyBackups", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		MaxSize:         10,
+		ReadOnlyBackups: true,
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+
+	info, err := os.Stat(backupFile(dir))
+	isNil(err, t)
+	equals(os.FileMode(0), info.Mode().Perm()&0222, t)
+
+	// the new log file is still writable.
+	info, err = os.Stat(logFile(dir))
+	isNil(err, t)
+	assert(info.Mode().Perm()&0200 != 0, t, "log file isn't writable: %v", info.Mode())
+}
+
+func TestReadOnlyBackupsCompressed(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestReadOnlyBackupsCompressed", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:        logFile(dir),
+		MaxSize:         10,
+		MaxBackups:      1,
+		Compress:        true,
+		ReadOnlyBackups: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+
+	newFakeTime()
+	first := backupFile(dir) + compressSuffix
+	err = l.Rotate()
+	isNil(err, t)
+	<-time.After(300 * time.Millisecond)
+
+	info, err := os.Stat(first)
+	isNil(err, t)
+	equals(os.FileMode(0), info.Mode().Perm()&0222, t)
+
+	// read-only backups are still removed when they're too old.
+	newFakeTime()
+	err = l.Rotate()
+	isNil(err, t)
+	<-time.After(300 * time.Millisecond)
+	notExist(first, t)
+	isNil(l.LastError(), t)
+}
@@ -0,0 +1,40 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+)
+
+// reconcile checks the open log file against the one on disk, if it's time
+// to, and sorts out any differences before a write of writeLen bytes.
+func (l *Logger) reconcile(writeLen int) error {
+	if l.ReconcileInterval <= 0 || l.file == nil || l.passthrough || l.handingOff() {
+		return nil
+	}
+	now := currentTime()
+	if now.Sub(l.lastReconcile) < l.ReconcileInterval {
+		return nil
+	}
+	l.lastReconcile = now
+
+	name := l.filename()
+	open, err := l.file.Stat()
+	if err != nil {
+		return fmt.Errorf("can't stat open log file: %w", err)
+	}
+	onDisk, err := l.stat(name)
+	if err != nil || !os.SameFile(open, onDisk) {
+		cause := fmt.Errorf("log file %s has been removed or replaced", name)
+		l.emit(Event{Type: EventReconcile, Filename: name, Err: cause})
+		if err := l.close(); err != nil {
+			return err
+		}
+		return l.openExistingOrNew(writeLen)
+	}
+	if size := open.Size(); size != l.size {
+		cause := fmt.Errorf("log file %s is %d bytes, expected %d", name, size, l.size)
+		l.emit(Event{Type: EventReconcile, Filename: name, Size: size, Err: cause})
+		l.size = size
+	}
+	return nil
+}
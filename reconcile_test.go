@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReconcile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestReconcile", t)
+	defer os.RemoveAll(dir)
+
+	// the Logger appends to an existing file, so it doesn't trample what
+	// anyone else appends.
+	isNil(ioutil.WriteFile(logFile(dir), nil, 0644), t)
+
+	var events []Event
+	l := &Logger{
+		Filename:          logFile(dir),
+		ReconcileInterval: time.Minute,
+		OnEvent:           func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	// someone else appends to the file.
+	f, err := os.OpenFile(logFile(dir), os.O_APPEND|os.O_WRONLY, 0644)
+	isNil(err, t)
+	_, err = f.Write([]byte("extra"))
+	isNil(err, t)
+	isNil(f.Close(), t)
+
+	// not time to check yet.
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals(0, len(events), t)
+
+	fakeCurrentTime = fakeCurrentTime.Add(time.Minute)
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals(1, len(events), t)
+	equals(EventReconcile, events[0].Type, t)
+	equals(int64(13), events[0].Size, t)
+	equals(int64(17), l.size, t)
+
+	// someone else removes the file.
+	isNil(os.Remove(logFile(dir)), t)
+	fakeCurrentTime = fakeCurrentTime.Add(time.Minute)
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals(2, len(events), t)
+	equals(EventReconcile, events[1].Type, t)
+	existsWithContent(logFile(dir), b, t)
+	equals(int64(len(b)), l.size, t)
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package lumberjack
+
+// renameNoReplace renames oldpath to newpath, failing with an error that
+// wraps os.ErrExist if newpath already exists.  This platform can't do that
+// in one step, so there is a small window where newpath could be replaced,
+// which is reported by returning errRenameUnchecked as unchecked.
+func renameNoReplace(oldpath, newpath string) (unchecked, err error) {
+	return errRenameUnchecked, checkedRename(oldpath, newpath)
+}
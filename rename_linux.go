@@ -0,0 +1,57 @@
+package lumberjack
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// sysRenameat2 is the number of the renameat2 system call, which the syscall
+// package doesn't define for every architecture, or zero for an architecture
+// not listed here.
+var sysRenameat2 = map[string]uintptr{
+	"amd64":    316,
+	"386":      353,
+	"arm":      382,
+	"arm64":    276,
+	"riscv64":  276,
+	"loong64":  276,
+	"s390x":    347,
+	"ppc64":    357,
+	"ppc64le":  357,
+	"mips":     4351,
+	"mipsle":   4351,
+	"mips64":   5311,
+	"mips64le": 5311,
+}[runtime.GOARCH]
+
+// renameNoReplace renames oldpath to newpath, failing with an error that
+// wraps os.ErrExist if newpath already exists, rather than replacing it.  If
+// the kernel or filesystem can't do that, it checks that newpath is free and
+// then renames, returning why as unchecked.
+func renameNoReplace(oldpath, newpath string) (unchecked, err error) {
+	if sysRenameat2 == 0 {
+		return errRenameUnchecked, checkedRename(oldpath, newpath)
+	}
+	from, err := syscall.BytePtrFromString(oldpath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := syscall.BytePtrFromString(newpath)
+	if err != nil {
+		return nil, err
+	}
+	atFdcwd := -0x64
+	const renameNoreplace = 0x1
+	_, _, errno := syscall.Syscall6(sysRenameat2, uintptr(atFdcwd), uintptr(unsafe.Pointer(from)),
+		uintptr(atFdcwd), uintptr(unsafe.Pointer(to)), renameNoreplace, 0)
+	switch errno {
+	case 0:
+		return nil, nil
+	case syscall.ENOSYS, syscall.EINVAL:
+		// an old kernel, or a filesystem that doesn't support it.
+		return os.NewSyscallError("renameat2", errno), checkedRename(oldpath, newpath)
+	}
+	return nil, &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: errno}
+}
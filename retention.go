@@ -0,0 +1,253 @@
+package lumberjack
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// ageCutoff returns the time before which backups are too old to keep under
+// MaxAge.
+func (l *Logger) ageCutoff() (time.Time, error) {
+	now := currentTime()
+	if !l.CalendarDays {
+		diff := time.Duration(int64(24*time.Hour) * int64(l.MaxAge))
+		return now.Add(-1 * diff), nil
+	}
+	loc, err := l.location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := now.In(loc).Date()
+	// time.Date normalizes the day, and copes with days that don't start at
+	// midnight or aren't 24 hours long.
+	return time.Date(y, m, d-(l.MaxAge-1), 0, 0, 0, 0, loc), nil
+}
+
+// location returns the time zone that days are reckoned in.
+func (l *Logger) location() (*time.Location, error) {
+	if l.TimeZone != "" {
+		loc, err := time.LoadLocation(l.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("can't load time zone: %w", err)
+		}
+		return loc, nil
+	}
+	if l.LocalTime {
+		return time.Local, nil
+	}
+	return time.UTC, nil
+}
+
+// GFSPolicy is a grandfather-father-son retention scheme.  Every backup is
+// kept for the first All days, then the newest backup of each day is kept for
+// the next Daily days, then the newest of each week for the next Weekly
+// weeks, then the newest of each month for the next Monthly months.  Older
+// backups, and the backups not picked to represent their day, week or month,
+// are deleted.  Days, weeks and months are reckoned in the Logger's TimeZone,
+// with weeks starting on Monday.
+type GFSPolicy struct {
+	All     int `json:"all" yaml:"all"`
+	Daily   int `json:"daily" yaml:"daily"`
+	Weekly  int `json:"weekly" yaml:"weekly"`
+	Monthly int `json:"monthly" yaml:"monthly"`
+}
+
+// filter splits files, which are sorted newest first, into the ones the
+// policy keeps and the ones it removes.
+func (g *GFSPolicy) filter(files []logInfo, l *Logger) (keep, remove []logInfo, err error) {
+	loc, err := l.location()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := currentTime().In(loc)
+	allEnd := now.AddDate(0, 0, -g.All)
+	dailyEnd := allEnd.AddDate(0, 0, -g.Daily)
+	weeklyEnd := dailyEnd.AddDate(0, 0, -7*g.Weekly)
+	monthlyEnd := weeklyEnd.AddDate(0, -g.Monthly, 0)
+
+	seen := map[string]bool{}
+	kept := map[string]bool{}
+	for _, f := range files {
+		// a backup being compressed goes with its compressed version.
+		base := trimCompressExt(f.Name())
+		if kept[base] {
+			keep = append(keep, f)
+			continue
+		}
+
+		t := f.timestamp.In(loc)
+		var period string
+		switch {
+		case !t.Before(allEnd):
+		case !t.Before(dailyEnd):
+			period = t.Format("day 2006-01-02")
+		case !t.Before(weeklyEnd):
+			y, w := t.ISOWeek()
+			period = fmt.Sprintf("week %d-%d", y, w)
+		case !t.Before(monthlyEnd):
+			period = t.Format("month 2006-01")
+		default:
+			remove = append(remove, f)
+			continue
+		}
+		if period != "" {
+			if seen[period] {
+				remove = append(remove, f)
+				continue
+			}
+			seen[period] = true
+		}
+		kept[base] = true
+		keep = append(keep, f)
+	}
+	return keep, remove, nil
+}
+
+// ThinPolicy keeps every backup until it is After old, and from then on only
+// the newest backup in each Interval, such as an hour or a day, so that
+// frequent rotation doesn't mean choosing between keeping all of the older
+// history and keeping none of it.  Intervals are reckoned in the Logger's
+// TimeZone, so that days start at midnight, and weeks start on Monday.
+type ThinPolicy struct {
+	After    time.Duration `json:"after" yaml:"after"`
+	Interval time.Duration `json:"interval" yaml:"interval"`
+}
+
+// filter splits files, which are sorted newest first, into the ones the
+// policy keeps and the ones it removes.
+func (p *ThinPolicy) filter(files []logInfo, l *Logger) (keep, remove []logInfo, err error) {
+	if p.Interval <= 0 {
+		return files, nil, nil
+	}
+	loc, err := l.location()
+	if err != nil {
+		return nil, nil, err
+	}
+	cutoff := currentTime().Add(-p.After)
+
+	seen := map[time.Time]bool{}
+	kept := map[string]bool{}
+	for _, f := range files {
+		// a backup being compressed goes with its compressed version.
+		base := trimCompressExt(f.Name())
+		if kept[base] || !f.timestamp.Before(cutoff) {
+			kept[base] = true
+			keep = append(keep, f)
+			continue
+		}
+
+		// Truncate works in UTC from the zero time, which was a Monday, so
+		// shift into loc first.
+		_, offset := f.timestamp.In(loc).Zone()
+		t := f.timestamp.Add(time.Duration(offset) * time.Second)
+		interval := t.Truncate(p.Interval)
+		if seen[interval] {
+			remove = append(remove, f)
+			continue
+		}
+		seen[interval] = true
+		kept[base] = true
+		keep = append(keep, f)
+	}
+	return keep, remove, nil
+}
+
+// excluded reports whether the backup called name is pinned, going by pins,
+// or is one that PruneExclude or PruneExcludeFunc says to leave alone.
+func (l *Logger) excluded(name string, pins map[string]bool) bool {
+	if pins[trimCompressExt(name)] {
+		return true
+	}
+	for _, pattern := range l.PruneExclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return l.PruneExcludeFunc != nil && l.PruneExcludeFunc(name)
+}
+
+// prunable returns files without the backups that cleanup leaves alone.
+func (l *Logger) prunable(files []logInfo) []logInfo {
+	pins := l.pins()
+	if len(pins) == 0 && len(l.PruneExclude) == 0 && l.PruneExcludeFunc == nil {
+		return files
+	}
+	var keep []logInfo
+	for _, f := range files {
+		if !l.excluded(f.Name(), pins) {
+			keep = append(keep, f)
+		}
+	}
+	return keep
+}
+
+// perDayFilter splits files, which are sorted newest first, into the newest
+// MaxBackupsPerDay of each day and the rest.
+func (l *Logger) perDayFilter(files []logInfo) (keep, remove []logInfo, err error) {
+	loc, err := l.location()
+	if err != nil {
+		return nil, nil, err
+	}
+	perDay := map[string]int{}
+	kept := map[string]bool{}
+	for _, f := range files {
+		// a backup being compressed goes with its compressed version.
+		base := trimCompressExt(f.Name())
+		if kept[base] {
+			keep = append(keep, f)
+			continue
+		}
+		day := f.timestamp.In(loc).Format("2006-01-02")
+		if perDay[day] >= l.MaxBackupsPerDay {
+			remove = append(remove, f)
+			continue
+		}
+		perDay[day]++
+		kept[base] = true
+		keep = append(keep, f)
+	}
+	return keep, remove, nil
+}
+
+// budgetFilter splits files, which are sorted newest first, into the newest
+// ones that fit within MaxTotalSize along with the log file, and the rest.
+func (l *Logger) budgetFilter(files []logInfo) (keep, remove []logInfo) {
+	budget := int64(l.MaxTotalSize) * int64(megabyte)
+	var total int64
+	if info, err := l.stat(l.filename()); err == nil {
+		total = info.Size()
+	}
+	for _, f := range files {
+		total += f.Size()
+		if total > budget {
+			remove = append(remove, f)
+		} else {
+			keep = append(keep, f)
+		}
+	}
+	var kept int64
+	for _, f := range keep {
+		kept += f.Size()
+	}
+	atomic.StoreInt64(&l.backupBytes, kept)
+	return keep, remove
+}
+
+// checkTotalSize starts a cleanup if the log file and backups have just
+// grown beyond MaxTotalSize, the log file having been prev bytes before the
+// write.  Once they are over, later writes don't start another cleanup, since
+// whatever is left over then can't be removed; the next rotation's cleanup
+// tries again.  It is called after each write, with the lock held.
+func (l *Logger) checkTotalSize(prev int64) {
+	if l.MaxTotalSize <= 0 || l.passthrough || l.maintenancePaused() {
+		return
+	}
+	backups := atomic.LoadInt64(&l.backupBytes)
+	limit := int64(l.MaxTotalSize) * int64(megabyte)
+	// with no backups there's nothing that cleaning up could remove.
+	if backups > 0 && prev+backups <= limit && l.size+backups > limit {
+		l.mill()
+	}
+}
@@ -0,0 +1,319 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalendarDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+	now := time.Date(2020, 3, 10, 1, 0, 0, 0, ny)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestCalendarDays", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxAge:       2,
+		CalendarDays: true,
+		TimeZone:     "America/New_York",
+	}
+	defer l.Close()
+
+	cutoff, err := l.ageCutoff()
+	isNil(err, t)
+	equals(time.Date(2020, 3, 9, 0, 0, 0, 0, ny).UTC(), cutoff.UTC(), t)
+
+	// 00:30 on the 9th in New York, so kept, and 23:30 on the 8th, so not,
+	// although both are well within 48 hours.
+	kept := filepath.Join(dir, "foobar-2020-03-09T04-30-00.000.log")
+	removed := filepath.Join(dir, "foobar-2020-03-09T03-30-00.000.log")
+	isNil(ioutil.WriteFile(kept, []byte("kept"), 0644), t)
+	isNil(ioutil.WriteFile(removed, []byte("removed"), 0644), t)
+
+	isNil(l.millRunOnce(), t)
+	exists(kept, t)
+	notExist(removed, t)
+}
+
+func TestCalendarDaysBadTimeZone(t *testing.T) {
+	l := &Logger{
+		MaxAge:       2,
+		CalendarDays: true,
+		TimeZone:     "Nowhere/Special",
+	}
+	_, err := l.ageCutoff()
+	notNil(err, t)
+}
+
+func TestGFS(t *testing.T) {
+	// a Tuesday.
+	now := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestGFS", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		GFS:      &GFSPolicy{All: 1, Daily: 2, Weekly: 2, Monthly: 1},
+	}
+	defer l.Close()
+
+	backup := func(month time.Month, day, hour int) string {
+		ts := time.Date(2020, month, day, hour, 0, 0, 0, time.UTC).Format(backupTimeFormat)
+		name := filepath.Join(dir, "foobar-"+ts+".log")
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		return name
+	}
+	kept := []string{
+		backup(6, 30, 10), // everything from the last day
+		backup(6, 29, 13),
+		backup(6, 29, 9), // newest of each of the next two days
+		backup(6, 28, 10),
+		backup(6, 27, 13),
+		backup(6, 25, 0), // newest of each of the next two weeks
+		backup(6, 15, 0),
+		backup(6, 1, 0), // newest of each month in the month after that
+		backup(5, 20, 0),
+	}
+	removed := []string{
+		backup(6, 29, 8),
+		backup(6, 23, 0),
+		backup(5, 14, 0),
+		backup(5, 1, 0),
+	}
+
+	isNil(l.millRunOnce(), t)
+	for _, name := range kept {
+		exists(name, t)
+	}
+	for _, name := range removed {
+		notExist(name, t)
+	}
+}
+
+func TestThin(t *testing.T) {
+	now := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestThin", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		TimeZone: "America/New_York",
+		Thin:     &ThinPolicy{After: 24 * time.Hour, Interval: 24 * time.Hour},
+	}
+	defer l.Close()
+
+	backup := func(day, hour, min int) string {
+		ts := time.Date(2020, 6, day, hour, min, 0, 0, time.UTC).Format(backupTimeFormat)
+		name := filepath.Join(dir, "foobar-"+ts+".log")
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		return name
+	}
+	kept := []string{
+		backup(30, 11, 0), // everything from the last day
+		backup(29, 13, 0),
+		backup(29, 12, 59),
+		backup(29, 5, 0), // newest of each New York day after that
+		backup(29, 3, 0),
+		backup(20, 0, 0),
+	}
+	removed := []string{
+		backup(29, 4, 0),
+		backup(28, 5, 0),
+	}
+
+	isNil(l.millRunOnce(), t)
+	for _, name := range kept {
+		exists(name, t)
+	}
+	for _, name := range removed {
+		notExist(name, t)
+	}
+}
+
+func TestMaxBackupsPerDay(t *testing.T) {
+	now := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestMaxBackupsPerDay", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:         logFile(dir),
+		TimeZone:         "America/New_York",
+		MaxBackupsPerDay: 2,
+	}
+	defer l.Close()
+
+	backup := func(day, hour, min int) string {
+		ts := time.Date(2020, 6, day, hour, min, 0, 0, time.UTC).Format(backupTimeFormat)
+		name := filepath.Join(dir, "foobar-"+ts+".log")
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		return name
+	}
+	kept := []string{
+		backup(30, 11, 0), // the newest two of each New York day
+		backup(30, 10, 0),
+		backup(30, 3, 0),
+		backup(29, 5, 0),
+		backup(20, 0, 0),
+	}
+	removed := []string{
+		backup(30, 9, 0),
+		backup(30, 8, 0),
+		backup(29, 4, 0),
+	}
+	// a compressed copy left beside its backup counts as the same one.
+	gz := kept[0] + compressSuffix
+	isNil(ioutil.WriteFile(gz, []byte("data"), 0644), t)
+	kept = append(kept, gz)
+
+	isNil(l.millRunOnce(), t)
+	for _, name := range kept {
+		exists(name, t)
+	}
+	for _, name := range removed {
+		notExist(name, t)
+	}
+}
+
+func TestPruneExclude(t *testing.T) {
+	now := time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+	currentTime = func() time.Time { return now }
+	defer func() { currentTime = fakeTime }()
+
+	dir := makeTempDir("TestPruneExclude", t)
+	defer os.RemoveAll(dir)
+
+	backup := func(day int) string {
+		ts := time.Date(2020, 6, day, 0, 0, 0, 0, time.UTC).Format(backupTimeFormat)
+		name := filepath.Join(dir, "foobar-"+ts+".log")
+		isNil(ioutil.WriteFile(name, []byte("data"), 0644), t)
+		return name
+	}
+	newest := backup(29)
+	removed := backup(28)
+	pattern := backup(27)
+	pinned := backup(26)
+
+	l := &Logger{
+		Filename:         logFile(dir),
+		MaxBackups:       1,
+		Compress:         true,
+		PruneExclude:     []string{"[", "foobar-2020-06-27*"},
+		PruneExcludeFunc: func(name string) bool { return name == filepath.Base(pinned) },
+	}
+	defer l.Close()
+
+	isNil(l.millRunOnce(), t)
+	// the backups left alone don't count toward MaxBackups, and aren't
+	// compressed.
+	exists(newest+compressSuffix, t)
+	notExist(removed, t)
+	exists(pattern, t)
+	exists(pinned, t)
+	fileCount(dir, 3, t)
+}
+
+func TestMaxTotalSize(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMaxTotalSize", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxSize:      10,
+		MaxTotalSize: 25,
+	}
+	defer l.Close()
+
+	// manual rotations of small files still count against the total.
+	var backups []string
+	for i := 0; i < 4; i++ {
+		_, err := l.Write([]byte("0123456"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		backups = append(backups, backupFile(dir))
+	}
+	<-time.After(10 * time.Millisecond)
+
+	// the log file is empty, so three backups of 7 bytes fit.
+	notExist(backups[0], t)
+	exists(backups[1], t)
+
+	// growing the log file pushes out more backups, without a rotation.
+	_, err := l.Write([]byte("0123456789"))
+	isNil(err, t)
+	<-time.After(10 * time.Millisecond)
+	notExist(backups[1], t)
+	exists(backups[2], t)
+	exists(backups[3], t)
+}
+
+func TestMaxTotalSizeTriggers(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMaxTotalSizeTriggers", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:     logFile(dir),
+		MaxSize:      100,
+		MaxTotalSize: 10,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("012"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(10 * time.Millisecond)
+	backup := backupFile(dir)
+	exists(backup, t)
+
+	// while maintenance is paused, going over doesn't start a cleanup.
+	l.PauseMaintenance()
+	triggers := l.Stats().MillTriggers
+	for i := 0; i < 5; i++ {
+		_, err := l.Write([]byte("0123"))
+		isNil(err, t)
+	}
+	equals(triggers, l.Stats().MillTriggers, t)
+	exists(backup, t)
+
+	// otherwise only the write that takes the total over the limit does.
+	l.ResumeMaintenance()
+	<-time.After(10 * time.Millisecond)
+	notExist(backup, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("012"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(10 * time.Millisecond)
+	exists(backupFile(dir), t)
+	triggers = l.Stats().MillTriggers
+	_, err = l.Write([]byte("0123"))
+	isNil(err, t)
+	equals(triggers, l.Stats().MillTriggers, t)
+	_, err = l.Write([]byte("0123"))
+	isNil(err, t)
+	equals(triggers+1, l.Stats().MillTriggers, t)
+}
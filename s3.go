@@ -0,0 +1,179 @@
+package lumberjack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ensure we always implement Uploader
+var _ Uploader = (*S3Uploader)(nil)
+
+// S3Uploader uploads files to a bucket in Amazon S3 or an S3-compatible
+// object store such as MinIO, using path-style requests signed with AWS
+// Signature Version 4.
+type S3Uploader struct {
+	// Endpoint is the base URL of the object store, such as
+	// "http://localhost:9000".  It defaults to the Amazon S3 endpoint for
+	// Region.
+	Endpoint string `json:"endpoint" yaml:"endpoint"`
+
+	// Region is the region the bucket is in, such as "us-east-1".
+	Region string `json:"region" yaml:"region"`
+
+	// Bucket is the name of the bucket to upload to.
+	Bucket string `json:"bucket" yaml:"bucket"`
+
+	// Prefix is prepended to the file name to make the object key, for
+	// example "logs/myapp/".
+	Prefix string `json:"prefix" yaml:"prefix"`
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials to
+	// sign requests with.  SessionToken is only needed for temporary
+	// credentials.
+	AccessKeyID     string `json:"accesskeyid" yaml:"accesskeyid"`
+	SecretAccessKey string `json:"secretaccesskey" yaml:"secretaccesskey"`
+	SessionToken    string `json:"sessiontoken" yaml:"sessiontoken"`
+
+	// Client is the HTTP client to make requests with.  It defaults to
+	// http.DefaultClient.
+	Client *http.Client `json:"-" yaml:"-"`
+}
+
+// Upload uploads the file at localPath to the bucket, using Prefix followed by
+// objectName as the key.
+func (s *S3Uploader) Upload(ctx context.Context, localPath, objectName string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	url := s.endpoint() + "/" + s3Escape(s.Bucket) + "/" + s3Escape(s.Prefix+objectName)
+	req, err := http.NewRequest(http.MethodPut, url, ioutil.NopCloser(f))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = size
+	s.sign(req, hex.EncodeToString(h.Sum(nil)), currentTime())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 upload of %s failed: %s: %s", objectName, resp.Status, body)
+	}
+	return nil
+}
+
+// endpoint returns the base URL to send requests to.
+func (s *S3Uploader) endpoint() string {
+	if s.Endpoint != "" {
+		return strings.TrimSuffix(s.Endpoint, "/")
+	}
+	return "https://s3." + s.Region + ".amazonaws.com"
+}
+
+// sign adds the AWS Signature Version 4 Authorization header to req, signing
+// the host and every header already set on the request.
+func (s *S3Uploader) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	crHash := sha256.Sum256([]byte(canonRequest))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(crHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, sig,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape escapes an object key for use in a URL path the way S3 expects,
+// leaving only unreserved characters and slashes as they are.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
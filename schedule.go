@@ -0,0 +1,46 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// scheduleName returns the name of the file in which KeepSchedule records
+// when the log file name is next due to be rotated.  It starts with a dot
+// so that it isn't taken for a backup.
+func scheduleName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".schedule")
+}
+
+// saveSchedule records, if KeepSchedule is set, when RotateInterval next
+// calls for the log file, which has just been opened, to be rotated.  The
+// record is only a hint, so failing to write it isn't an error.
+func (l *Logger) saveSchedule() {
+	if !l.KeepSchedule || l.RotateInterval <= 0 {
+		return
+	}
+	next := l.openedAt.Add(l.RotateInterval).UTC().Format(time.RFC3339Nano)
+	writeFileAtomic(scheduleName(l.filename()), []byte(next+"\n"), 0644)
+}
+
+// restoreSchedule, for a log file that was already there, brings the time
+// it was opened back to when it was started, going by the time recorded by
+// saveSchedule, so that RotateInterval is measured from then.
+func (l *Logger) restoreSchedule() {
+	if !l.KeepSchedule || l.RotateInterval <= 0 {
+		return
+	}
+	b, err := ioutil.ReadFile(scheduleName(l.filename()))
+	if err != nil {
+		return
+	}
+	next, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(b)))
+	if err != nil {
+		return
+	}
+	if started := next.Add(-l.RotateInterval); started.Before(l.openedAt) {
+		l.openedAt = started
+	}
+}
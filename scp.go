@@ -0,0 +1,71 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path"
+	"strconv"
+)
+
+// ensure we always implement Uploader
+var _ Uploader = (*SCPUploader)(nil)
+
+// SCPUploader ships files to a remote host over SSH by running the system's
+// scp command.  It tells scp to use the SFTP protocol, rather than the legacy
+// one, so that the remote path isn't parsed by a shell on the remote host and
+// Dir and the backup names can hold spaces or any other characters safely;
+// that needs OpenSSH 8.7 or later, or another scp that takes the -s flag.
+// Authentication must work without prompting, for example with a key.
+type SCPUploader struct {
+	// Host is the remote host, optionally with a user, as in
+	// "backup@logs.example.com".
+	Host string `json:"host" yaml:"host"`
+
+	// Port is the SSH port on the remote host.  It defaults to scp's default.
+	Port int `json:"port" yaml:"port"`
+
+	// Dir is the directory on the remote host to copy files into.
+	Dir string `json:"dir" yaml:"dir"`
+
+	// IdentityFile is the private key to authenticate with.  It defaults to
+	// scp's default.
+	IdentityFile string `json:"identityfile" yaml:"identityfile"`
+
+	// Command is the scp command to run.  It defaults to "scp".
+	Command string `json:"command" yaml:"command"`
+
+	// Args are extra arguments to pass to the command, such as
+	// "-o", "StrictHostKeyChecking=yes".
+	Args []string `json:"args" yaml:"args"`
+}
+
+// Upload copies the file at localPath to Dir on the remote host, naming it
+// objectName.
+func (s *SCPUploader) Upload(ctx context.Context, localPath, objectName string) error {
+	command := s.Command
+	if command == "" {
+		command = "scp"
+	}
+	out, err := exec.CommandContext(ctx, command, s.args(localPath, objectName)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("scp of %s failed: %w: %s", objectName, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// args returns the arguments to the scp command.
+func (s *SCPUploader) args(localPath, objectName string) []string {
+	// batch mode, so we fail rather than prompt for a password, and SFTP,
+	// so that the remote path is taken as it is.
+	args := []string{"-q", "-B", "-s"}
+	if s.Port != 0 {
+		args = append(args, "-P", strconv.Itoa(s.Port))
+	}
+	if s.IdentityFile != "" {
+		args = append(args, "-i", s.IdentityFile)
+	}
+	args = append(args, s.Args...)
+	return append(args, localPath, s.Host+":"+path.Join(s.Dir, objectName))
+}
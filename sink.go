@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// sizeWriter counts the bytes written through it to w.
+type sizeWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (s *sizeWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	s.n += int64(n)
+	return n, err
+}
+
+// compressToSink compresses the backup src with c to a writer that
+// CompressSink makes for name, and removes src once that writer has been
+// closed successfully.  It returns the size of the compressed backup.  If
+// compressing fails, the writer is closed with CloseWithError, if it has that
+// method, as io.PipeWriter does, so the partial backup can be thrown away.
+func (l *Logger) compressToSink(src, name string, c *codec) (int64, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer f.Close()
+
+	fi, err := l.stat(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	w, err := l.CompressSink(name)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open compressed log file: %w", err)
+	}
+	sw := &sizeWriter{w: w}
+	if err := c.compress(sw, l.compressSource(f), fi, l.CompressKeepName); err != nil {
+		if a, ok := w.(interface{ CloseWithError(error) error }); ok {
+			a.CloseWithError(err)
+		} else {
+			w.Close()
+		}
+		return 0, fmt.Errorf("failed to compress log file: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress log file: %w", err)
+	}
+
+	f.Close()
+	if err := removeFile(src); err != nil {
+		return sw.n, err
+	}
+	return sw.n, nil
+}
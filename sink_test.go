@@ -0,0 +1,108 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// bufferSink is a compressed backup written to memory.
+type bufferSink struct {
+	bytes.Buffer
+	closed  bool
+	failure error
+}
+
+func (b *bufferSink) Close() error {
+	b.closed = true
+	return nil
+}
+
+func (b *bufferSink) CloseWithError(err error) error {
+	b.failure = err
+	return nil
+}
+
+func TestCompressSink(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCompressSink", t)
+	defer os.RemoveAll(dir)
+
+	sinks := map[string]*bufferSink{}
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		ManualCleanup: true,
+		CompressSink: func(name string) (io.WriteCloser, error) {
+			s := &bufferSink{}
+			sinks[name] = s
+			return s, nil
+		},
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.CleanNow(), t)
+
+	// the backup went to the sink rather than being compressed locally.
+	notExist(backupFile(dir), t)
+	notExist(backupFile(dir)+compressSuffix, t)
+	fileCount(dir, 1, t)
+
+	name := backupFile(dir)[len(dir)+1:] + compressSuffix
+	s := sinks[name]
+	notNil(s, t)
+	equals(true, s.closed, t)
+	r, err := gzip.NewReader(&s.Buffer)
+	isNil(err, t)
+	got, err := ioutil.ReadAll(r)
+	isNil(err, t)
+	equals(b, got, t)
+}
+
+// failingSink fails every write.
+type failingSink struct {
+	bufferSink
+}
+
+func (f *failingSink) Write([]byte) (int, error) {
+	return 0, errors.New("sink is broken")
+}
+
+func TestCompressSinkFailure(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestCompressSinkFailure", t)
+	defer os.RemoveAll(dir)
+
+	sink := &failingSink{}
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		ManualCleanup: true,
+		CompressSink: func(string) (io.WriteCloser, error) {
+			return sink, nil
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	err = l.CleanNow()
+	assert(errors.Is(err, ErrCompressFailed), t, "expected ErrCompressFailed, got %v", err)
+
+	// the backup is kept to try again, and the sink told to throw its
+	// partial copy away.
+	existsWithContent(backupFile(dir), []byte("boo!"), t)
+	notNil(sink.failure, t)
+	equals(false, sink.closed, t)
+}
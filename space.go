@@ -0,0 +1,58 @@
+package lumberjack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SpaceRemaining reports how many more bytes can be written to the log file
+// before it is rotated, and how many bytes are free on the disk that holds
+// it, so that a program can check there is room before writing something
+// big, such as a debug dump.  The first is -1 if the log file is one that is
+// never rotated, such as a FIFO.  The error is set, and disk is zero, if the
+// free space can't be found, which it can't on some platforms.
+func (l *Logger) SpaceRemaining() (file int64, disk uint64, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case l.passingThrough():
+		file = -1
+	case l.file != nil:
+		file = l.max() - l.size
+	default:
+		file = l.max()
+		if info, err := l.stat(l.filename()); err == nil {
+			file -= info.Size()
+		}
+	}
+	if file < 0 && !l.passingThrough() {
+		file = 0
+	}
+
+	dir := l.dir()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// the directory will be made when the log file is first opened.
+		dir = existingParent(dir)
+	}
+	disk, _, err = diskSpace(dir)
+	if err != nil {
+		return file, 0, fmt.Errorf("can't get free disk space: %w", err)
+	}
+	return file, disk, nil
+}
+
+// existingParent returns the nearest parent of dir that exists.
+func existingParent(dir string) string {
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSpaceRemaining(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestSpaceRemaining", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: filepath.Join(dir, "sub", "foobar.log"),
+		MaxSize:  100,
+	}
+	defer l.Close()
+
+	file, disk, err := l.SpaceRemaining()
+	equals(int64(100), file, t)
+	if _, _, derr := diskSpace(dir); derr == nil {
+		isNil(err, t)
+		assert(disk > 0, t, "expected some free disk space")
+	}
+
+	_, err = l.Write(make([]byte, 30))
+	isNil(err, t)
+	file, _, _ = l.SpaceRemaining()
+	equals(int64(70), file, t)
+}
@@ -0,0 +1,73 @@
+package lumberjack
+
+import (
+	"bytes"
+	"errors"
+)
+
+// SplitWriter is an io.WriteCloser that sends each write to one of several
+// Loggers, so that, for example, errors can go to their own file with its own
+// size and retention settings.  Classify picks a key for each write, and the
+// write goes to the Logger for that key in Loggers, or to Default if there
+// isn't one.  Writes that have nowhere to go are dropped.
+type SplitWriter struct {
+	// Classify returns the key of the Logger that p should be written to.
+	// PrefixClassifier makes one that looks at how writes start.
+	Classify func(p []byte) string
+
+	// Loggers are the Loggers to write to, by key.
+	Loggers map[string]*Logger
+
+	// Default is the Logger for writes whose key isn't in Loggers.
+	Default *Logger
+}
+
+// Write implements io.Writer.
+func (s *SplitWriter) Write(p []byte) (int, error) {
+	l := s.Default
+	if s.Classify != nil {
+		if ll, ok := s.Loggers[s.Classify(p)]; ok {
+			l = ll
+		}
+	}
+	if l == nil {
+		return len(p), nil
+	}
+	return l.Write(p)
+}
+
+// Close implements io.Closer, closing all of the Loggers.
+func (s *SplitWriter) Close() error {
+	var errs []error
+	closed := map[*Logger]bool{}
+	for _, l := range s.Loggers {
+		if l != nil && !closed[l] {
+			closed[l] = true
+			errs = append(errs, l.Close())
+		}
+	}
+	if s.Default != nil && !closed[s.Default] {
+		errs = append(errs, s.Default.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// PrefixClassifier returns a classifier for SplitWriter made from pairs of a
+// prefix and a key.  Each write is classified with the key of the first prefix
+// it starts with, ignoring leading spaces, or as "" if it starts with none of
+// them.  For example, with
+//
+//	PrefixClassifier("ERROR", "error", "WARN", "error")
+//
+// writes starting with "ERROR" or "WARN" are classified as "error".
+func PrefixClassifier(prefixes ...string) func(p []byte) string {
+	return func(p []byte) string {
+		p = bytes.TrimLeft(p, " ")
+		for i := 0; i+1 < len(prefixes); i += 2 {
+			if bytes.HasPrefix(p, []byte(prefixes[i])) {
+				return prefixes[i+1]
+			}
+		}
+		return ""
+	}
+}
@@ -0,0 +1,40 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitWriter(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestSplitWriter", t)
+	defer os.RemoveAll(dir)
+
+	app := filepath.Join(dir, "app.log")
+	errs := filepath.Join(dir, "error.log")
+	s := &SplitWriter{
+		Classify: PrefixClassifier("ERROR", "error", "WARN", "error"),
+		Loggers: map[string]*Logger{
+			"error": {Filename: errs, MaxBackups: 10},
+		},
+		Default: &Logger{Filename: app, MaxBackups: 3},
+	}
+
+	for _, line := range []string{"INFO ok\n", "ERROR bad\n", " WARN hmm\n", "DEBUG x\n"} {
+		n, err := s.Write([]byte(line))
+		isNil(err, t)
+		equals(len(line), n, t)
+	}
+	isNil(s.Close(), t)
+	existsWithContent(app, []byte("INFO ok\nDEBUG x\n"), t)
+	existsWithContent(errs, []byte("ERROR bad\n WARN hmm\n"), t)
+
+	// with no Default, unclassified writes are dropped.
+	s.Default = nil
+	n, err := s.Write([]byte("INFO dropped\n"))
+	isNil(err, t)
+	equals(len("INFO dropped\n"), n, t)
+	isNil(s.Close(), t)
+	existsWithContent(app, []byte("INFO ok\nDEBUG x\n"), t)
+}
@@ -0,0 +1,125 @@
+package lumberjack
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the work a Logger has done since it was created.
+type Stats struct {
+	// Compressions is how many backups have been compressed.
+	Compressions int64
+
+	// CompressionInput and CompressionOutput are the total sizes in bytes of
+	// the backups before and after compression.
+	CompressionInput  int64
+	CompressionOutput int64
+
+	// CompressionTime is the total time spent compressing backups.
+	CompressionTime time.Duration
+
+	// LastCompression describes the most recent compression.
+	LastCompression CompressionStats
+
+	// MillRuns is how many rounds of compression and removal of old log
+	// files have run, and MillTime is the total time they took.
+	MillRuns int64
+	MillTime time.Duration
+
+	// MillTriggers is how many times a background round of cleanup has been
+	// asked for, and MillCoalesced is how many of those were folded into a
+	// round that was already waiting to run.  If MillCoalesced grows about as
+	// fast as MillTriggers, cleanup is falling behind the rotations.
+	MillTriggers  int64
+	MillCoalesced int64
+
+	// MillPending is whether a background round of cleanup is waiting to run.
+	MillPending bool
+
+	// LastMill describes the most recent round of cleanup.
+	LastMill MillStats
+}
+
+// MillStats describes a single round of compression and removal of old log
+// files.
+type MillStats struct {
+	// Duration is how long the round took.
+	Duration time.Duration
+
+	// Compressed and Removed are how many backups were compressed and
+	// removed.
+	Compressed int
+	Removed    int
+}
+
+// CompressionRatio returns the overall ratio of compressed to uncompressed
+// size, or zero if nothing has been compressed.
+func (s Stats) CompressionRatio() float64 {
+	if s.CompressionInput == 0 {
+		return 0
+	}
+	return float64(s.CompressionOutput) / float64(s.CompressionInput)
+}
+
+// CompressionStats describes the compression of a single backup.
+type CompressionStats struct {
+	// Filename is the compressed backup.
+	Filename string
+
+	// InputSize and OutputSize are the sizes in bytes of the backup before
+	// and after compression.
+	InputSize  int64
+	OutputSize int64
+
+	// Duration is how long compressing the backup took.
+	Duration time.Duration
+}
+
+// Ratio returns the ratio of compressed to uncompressed size, or zero if the
+// backup was empty.
+func (c CompressionStats) Ratio() float64 {
+	if c.InputSize == 0 {
+		return 0
+	}
+	return float64(c.OutputSize) / float64(c.InputSize)
+}
+
+// Stats returns a snapshot of the Logger's statistics.
+func (l *Logger) Stats() Stats {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	s := l.stats
+	s.MillPending = atomic.LoadInt32(&l.millPending) > 0
+	return s
+}
+
+// recordCompression adds a compression to the statistics.
+func (l *Logger) recordCompression(c CompressionStats) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.Compressions++
+	l.stats.CompressionInput += c.InputSize
+	l.stats.CompressionOutput += c.OutputSize
+	l.stats.CompressionTime += c.Duration
+	l.stats.LastCompression = c
+}
+
+// recordMill adds a round of cleanup to the statistics.
+func (l *Logger) recordMill(m MillStats) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.MillRuns++
+	l.stats.MillTime += m.Duration
+	l.stats.LastMill = m
+}
+
+// recordMillTrigger adds a request for a round of cleanup to the statistics,
+// noting whether it was coalesced with one already waiting.
+func (l *Logger) recordMillTrigger(coalesced bool) {
+	l.statsMu.Lock()
+	defer l.statsMu.Unlock()
+	l.stats.MillTriggers++
+	if coalesced {
+		l.stats.MillCoalesced++
+	}
+}
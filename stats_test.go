@@ -0,0 +1,89 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompressionStats(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCompressionStats", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  1000,
+		Compress: true,
+	}
+	defer l.Close()
+	equals(Stats{}, l.Stats(), t)
+
+	b := bytes.Repeat([]byte("boo!"), 100)
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(300 * time.Millisecond)
+
+	info, err := os.Stat(backupFile(dir) + compressSuffix)
+	isNil(err, t)
+
+	s := l.Stats()
+	equals(int64(1), s.Compressions, t)
+	equals(int64(len(b)), s.CompressionInput, t)
+	equals(info.Size(), s.CompressionOutput, t)
+	equals(s.CompressionTime, s.LastCompression.Duration, t)
+	equals(backupFile(dir)+compressSuffix, s.LastCompression.Filename, t)
+	equals(float64(info.Size())/float64(len(b)), s.LastCompression.Ratio(), t)
+	equals(s.LastCompression.Ratio(), s.CompressionRatio(), t)
+	assert(s.CompressionRatio() < 1, t, "repetitive data should compress, got ratio %v", s.CompressionRatio())
+}
+
+func TestMillStats(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestMillStats", t)
+	defer os.RemoveAll(dir)
+
+	for i := 0; i < 3; i++ {
+		newFakeTime()
+		isNil(ioutil.WriteFile(backupFile(dir), []byte("boo!"), 0644), t)
+	}
+	l := &Logger{
+		Filename:   logFile(dir),
+		MaxBackups: 1,
+		Compress:   true,
+	}
+	defer l.Close()
+
+	isNil(l.CleanNow(), t)
+	s := l.Stats()
+	equals(int64(1), s.MillRuns, t)
+	equals(2, s.LastMill.Removed, t)
+	equals(1, s.LastMill.Compressed, t)
+	equals(s.MillTime, s.LastMill.Duration, t)
+	fileCount(dir, 1, t)
+
+	// hold up the mill, so that triggers pile up behind the first.
+	l.millMu.Lock()
+	l.mill()
+	<-time.After(100 * time.Millisecond)
+	l.mill()
+	l.mill()
+	s = l.Stats()
+	equals(int64(3), s.MillTriggers, t)
+	equals(int64(1), s.MillCoalesced, t)
+	equals(true, s.MillPending, t)
+	l.millMu.Unlock()
+
+	<-time.After(300 * time.Millisecond)
+	s = l.Stats()
+	equals(false, s.MillPending, t)
+	equals(int64(3), s.MillRuns, t)
+	equals(MillStats{Duration: s.LastMill.Duration}, s.LastMill, t)
+}
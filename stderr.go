@@ -0,0 +1,33 @@
+package lumberjack
+
+import (
+	"io"
+	"os"
+)
+
+// stderr is where StderrFallback sends log data.  It is a variable so tests
+// can capture it.
+var stderr io.Writer = os.Stderr
+
+// writeStderr finishes a write of p, of which n bytes reached the log file
+// before err.  If err is set and StderrFallback is on, the rest of p goes to
+// stderr, prefixed with the log file's name, and the write counts as a
+// success.  Otherwise n and err are returned as they are.
+func (l *Logger) writeStderr(p []byte, n int, err error) (int, error) {
+	if err == nil {
+		l.onStderr = false
+		return n, nil
+	}
+	if !l.StderrFallback {
+		return n, err
+	}
+	if !l.onStderr {
+		l.onStderr = true
+		l.emit(Event{Type: EventStderr, Filename: l.primaryFilename(), Err: err})
+	}
+	buf := append([]byte("["+l.primaryFilename()+"] "), p[n:]...)
+	if _, serr := stderr.Write(buf); serr != nil {
+		return n, err
+	}
+	return len(p), nil
+}
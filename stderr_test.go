@@ -0,0 +1,54 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStderrFallback(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestStderrFallback", t)
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+	stderr = &buf
+	defer func() { stderr = os.Stderr }()
+
+	blocker := filepath.Join(dir, "primary")
+	err := ioutil.WriteFile(blocker, []byte("not a dir"), 0644)
+	isNil(err, t)
+	filename := filepath.Join(blocker, "foobar.log")
+
+	var events []Event
+	l := &Logger{
+		Filename:       filename,
+		StderrFallback: true,
+		OnEvent:        func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!\n")
+	n, err := l.Write(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	_, err = l.Write(b)
+	isNil(err, t)
+	equals("["+filename+"] boo!\n["+filename+"] boo!\n", buf.String(), t)
+
+	// only the switch to stderr is reported.
+	equals(1, len(events), t)
+	equals(EventStderr, events[0].Type, t)
+	notNil(events[0].Err, t)
+
+	// once the file works again, writes go back to it.
+	err = os.Remove(blocker)
+	isNil(err, t)
+	buf.Reset()
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(filename, b, t)
+	equals(0, buf.Len(), t)
+}
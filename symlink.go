@@ -0,0 +1,55 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// maxLinks is how many symlinks in a row FollowSymlink follows, as with
+// Linux's limit for path lookups.
+const maxLinks = 40
+
+// resolved returns the file that name points to, if FollowSymlink is set and
+// it is a symlink, or name otherwise.  What it points to is remembered until
+// forgetLink is called.
+func (l *Logger) resolved(name string) string {
+	if !l.FollowSymlink {
+		return name
+	}
+	l.linkMu.Lock()
+	defer l.linkMu.Unlock()
+	if l.linkFrom != name || l.linkTo == "" {
+		l.linkFrom, l.linkTo = name, resolveLink(name)
+	}
+	return l.linkTo
+}
+
+// forgetLink makes the next call to resolved look the symlink up again, in
+// case it has been pointed somewhere else.
+func (l *Logger) forgetLink() {
+	l.linkMu.Lock()
+	l.linkTo = ""
+	l.linkMu.Unlock()
+}
+
+// resolveLink follows name, and then what it points to, for as long as they
+// are symlinks, returning the first thing that isn't one, which may not
+// exist.  Only the last part of each name is followed, so that the result is
+// in the directory the symlink leads to.
+func resolveLink(name string) string {
+	for i := 0; i < maxLinks; i++ {
+		info, err := os.Lstat(name)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			return name
+		}
+		target, err := os.Readlink(name)
+		if err != nil {
+			return name
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(name), target)
+		}
+		name = target
+	}
+	return name
+}
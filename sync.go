@@ -0,0 +1,60 @@
+package lumberjack
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WriteAndSync is like Write, but also flushes the log file to stable storage
+// before returning, for records that must survive a crash, such as audit
+// events.  Everything written to the log file before p is flushed too.
+// BatchWindow and WriteTimeout don't apply, and an error is returned if p
+// could only be written to stderr.
+func (l *Logger) WriteAndSync(p []byte) (n int, err error) {
+	return l.instrumentWrite(p, func(p []byte) (int, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		n, err := l.write(p)
+		if err != nil {
+			return n, err
+		}
+		if err := l.sync(); err != nil {
+			return n, err
+		}
+		return n, nil
+	})
+}
+
+// sync flushes the open log file to stable storage.
+func (l *Logger) sync() error {
+	if l.file == nil || l.onStderr {
+		return errors.New("log file is not open")
+	}
+	if err := l.flushGzip(); err != nil {
+		return fmt.Errorf("can't flush log file: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("can't sync log file: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint brings the log files on disk up to date, for example before a
+// snapshot of the host is taken: it writes any batch of writes that is
+// waiting for BatchWindow, finishes any rotation that is under way, flushes
+// the log file to stable storage, and then compresses and removes old log
+// files as CleanNow does, waiting for that to finish.  The returned error
+// joins together anything that went wrong.
+func (l *Logger) Checkpoint() error {
+	l.flushBatch()
+
+	l.mu.Lock()
+	l.waitHandoff()
+	var err error
+	if l.file != nil {
+		err = l.sync()
+	}
+	l.mu.Unlock()
+
+	return errors.Join(err, l.CleanNow())
+}
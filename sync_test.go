@@ -0,0 +1,82 @@
+package lumberjack
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteAndSync(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteAndSync", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	b := []byte("boo!")
+	n, err := l.WriteAndSync(b)
+	isNil(err, t)
+	equals(len(b), n, t)
+	existsWithContent(logFile(dir), b, t)
+}
+
+func TestWriteAndSyncStderr(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWriteAndSyncStderr", t)
+	defer os.RemoveAll(dir)
+
+	stderr = ioutil.Discard
+	defer func() { stderr = os.Stderr }()
+
+	blocker := filepath.Join(dir, "primary")
+	isNil(ioutil.WriteFile(blocker, []byte("not a dir"), 0644), t)
+	l := &Logger{
+		Filename:       filepath.Join(blocker, "foobar.log"),
+		StderrFallback: true,
+	}
+	defer l.Close()
+
+	// the data isn't durable, so it's an error, even though Write wouldn't
+	// report one.
+	_, err := l.WriteAndSync([]byte("boo!"))
+	notNil(err, t)
+}
+
+func TestCheckpoint(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestCheckpoint", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		MaxSize:       10,
+		Compress:      true,
+		ManualCleanup: true,
+	}
+	defer l.Close()
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	exists(backupFile(dir), t)
+
+	// a write waiting for its batch is written by the checkpoint.
+	l.BatchWindow = time.Hour
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := l.Write([]byte("foo!"))
+		isNil(err, t)
+	}()
+	<-time.After(10 * time.Millisecond)
+
+	isNil(l.Checkpoint(), t)
+	<-done
+	existsWithContent(logFile(dir), []byte("foo!"), t)
+	notExist(backupFile(dir), t)
+	exists(backupFile(dir)+compressSuffix, t)
+}
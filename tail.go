@@ -0,0 +1,144 @@
+package lumberjack
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+)
+
+// tailChunk is how much of a log file Tail reads at a time, working backwards
+// from the end.
+const tailChunk = 32 * 1024
+
+// Tail returns the last n lines written to the log, oldest first, without
+// their trailing newlines.  If the current log file has fewer than n lines,
+// the rest are taken from the most recent backups.  Fewer than n lines are
+// returned if that's all there is.
+func (l *Logger) Tail(n int) ([][]byte, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	paths, err := l.backupPaths()
+	names := append([]string{l.filename()}, paths...)
+	// with CompressActive, the current log file can only be read through
+	// from the start, as far as it has been flushed.
+	var current io.ReadCloser
+	if err == nil && l.CompressActive && !l.passingThrough() {
+		names = paths
+		current, err = l.openCurrent()
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines [][]byte
+	if current != nil {
+		lines, err = tailStream(current, n)
+		current.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range names {
+		if len(lines) >= n {
+			break
+		}
+		more, err := tailFile(name, n-len(lines))
+		// a backup may have been compressed since it was listed.
+		if os.IsNotExist(err) {
+			if cname := compressedVersion(name); cname != "" {
+				more, err = tailFile(cname, n-len(lines))
+			}
+		}
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines = append(more, lines...)
+	}
+	return lines, nil
+}
+
+// tailFile returns the last n lines of the named file, which is decompressed
+// if needed.
+func tailFile(name string, n int) ([][]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if c := codecFor(name); c != nil {
+		r, err := c.decompress(f)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return tailStream(r, n)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var buf []byte
+	for pos := info.Size(); pos > 0; {
+		start := pos - tailChunk
+		if start < 0 {
+			start = 0
+		}
+		b := make([]byte, pos-start)
+		if _, err := f.ReadAt(b, start); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(b, buf...)
+		pos = start
+		if bytes.Count(bytes.TrimSuffix(buf, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+	return lastLines(buf, n), nil
+}
+
+// tailStream returns the last n lines read from r.
+func tailStream(r io.Reader, n int) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	var lines [][]byte
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			lines = append(lines, bytes.TrimSuffix(line, []byte("\n")))
+			if len(lines) > n {
+				lines = lines[1:]
+			}
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// lastLines splits b into lines and returns the last n of them.
+func lastLines(b []byte, n int) [][]byte {
+	b = bytes.TrimSuffix(b, []byte("\n"))
+	if len(b) == 0 {
+		return nil
+	}
+	lines := bytes.Split(b, []byte("\n"))
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines
+}
@@ -0,0 +1,80 @@
+package lumberjack
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTail(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+
+	dir := makeTempDir("TestTail", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename: logFile(dir),
+		MaxSize:  100,
+		Compress: true,
+	}
+	defer l.Close()
+
+	lines, err := l.Tail(3)
+	isNil(err, t)
+	equals(0, len(lines), t)
+
+	_, err = l.Write([]byte("a\nb\nc\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	_, err = l.Write([]byte("d\ne\n"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	// the last line has no newline yet.
+	_, err = l.Write([]byte("f\ng"))
+	isNil(err, t)
+
+	<-time.After(300 * time.Millisecond)
+
+	lines, err = l.Tail(1)
+	isNil(err, t)
+	equals([][]byte{[]byte("g")}, lines, t)
+
+	lines, err = l.Tail(4)
+	isNil(err, t)
+	equals([][]byte{[]byte("d"), []byte("e"), []byte("f"), []byte("g")}, lines, t)
+
+	lines, err = l.Tail(6)
+	isNil(err, t)
+	equals("b c d e f g", string(bytes.Join(lines, []byte(" "))), t)
+
+	lines, err = l.Tail(100)
+	isNil(err, t)
+	equals(7, len(lines), t)
+}
+
+func TestTailLongFile(t *testing.T) {
+	dir := makeTempDir("TestTailLongFile", t)
+	defer os.RemoveAll(dir)
+
+	// enough lines to need several chunks.
+	var data []byte
+	for i := 0; i < 20000; i++ {
+		data = append(data, "line\n"...)
+	}
+	data = append(data, "last\n"...)
+	name := logFile(dir)
+	isNil(ioutil.WriteFile(name, data, 0644), t)
+
+	lines, err := tailFile(name, 2)
+	isNil(err, t)
+	equals([][]byte{[]byte("line"), []byte("last")}, lines, t)
+
+	lines, err = tailFile(name, 15000)
+	isNil(err, t)
+	equals(15000, len(lines), t)
+}
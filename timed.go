@@ -0,0 +1,121 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// formatTimed replaces each {layout} in name with t formatted by layout.
+func formatTimed(name string, t time.Time) string {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(name, '{')
+		if i < 0 {
+			break
+		}
+		j := strings.IndexByte(name[i:], '}')
+		if j < 0 {
+			break
+		}
+		b.WriteString(name[:i])
+		b.WriteString(t.Format(name[i+1 : i+j]))
+		name = name[i+j+1:]
+	}
+	b.WriteString(name)
+	return b.String()
+}
+
+// timed fills in the time layouts in the base name of name if TimedFilename
+// is set.
+func (l *Logger) timed(name string) string {
+	if !l.TimedFilename {
+		return name
+	}
+	t := currentTime()
+	if !l.LocalTime {
+		t = t.UTC()
+	}
+	return filepath.Join(filepath.Dir(name), formatTimed(filepath.Base(name), t))
+}
+
+// timedTemplate returns the base name of the log file in use as configured,
+// with its time layouts still in braces.
+func (l *Logger) timedTemplate() string {
+	name := l.Filename
+	if l.fallbackActive() {
+		name = l.FallbackFilename
+	}
+	return filepath.Base(l.expanded(name))
+}
+
+// timedBackupTime reports whether name, in the log file's directory, is an
+// earlier log file made from the TimedFilename template, or a backup of one,
+// and if so the time in its name.
+func (l *Logger) timedBackupTime(name string) (time.Time, bool) {
+	if codecFor(name) != nil {
+		name = trimCompressExt(name)
+	}
+	if name == filepath.Base(l.filename()) {
+		return time.Time{}, false
+	}
+	loc := time.UTC
+	if l.LocalTime {
+		loc = time.Local
+	}
+	layout := strings.NewReplacer("{", "", "}", "").Replace(l.timedTemplate())
+	if t, err := time.ParseInLocation(layout, name, loc); err == nil {
+		return t, true
+	}
+
+	// a backup made by rotating an earlier log file when it got too big.
+	ext := filepath.Ext(layout)
+	stem := strings.TrimSuffix(name, ext)
+	if len(stem) == len(name) && ext != "" || len(stem) <= len(backupTimeFormat)+1 {
+		return time.Time{}, false
+	}
+	split := len(stem) - len(backupTimeFormat)
+	if stem[split-1] != '-' {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(backupTimeFormat, stem[split:], loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if _, err := time.ParseInLocation(layout, stem[:split-1]+ext, loc); err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// switchTimed switches to a new log file if TimedFilename is set and the
+// time in the log file's name has changed since it was opened, getting the
+// new file ready for a write of writeLen bytes.  The old log file is left as
+// it is, to be treated as a backup.
+func (l *Logger) switchTimed(writeLen int) error {
+	if !l.TimedFilename || l.file == nil || l.passthrough {
+		return nil
+	}
+	old := l.current
+	if l.filename() == old {
+		return nil
+	}
+	atomic.AddInt64(&l.rotations, 1)
+	if err := l.close(); err != nil {
+		return err
+	}
+	if info, err := l.stat(old); err == nil {
+		l.queueUpload(old)
+		l.linkBackup(old)
+		atomic.AddInt64(&l.backupBytes, info.Size())
+		l.emitRotate(Event{Type: EventRotate, Filename: old, Source: old, Size: info.Size(), Reason: RotateSchedule})
+		if !l.compresses(info.Size()) {
+			if err := l.makeReadOnly(old); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return l.openExistingOrNew(writeLen)
+}
@@ -0,0 +1,80 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFormatTimed(t *testing.T) {
+	tm := time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)
+	equals("app-2020-03-04.log", formatTimed("app-{2006-01-02}.log", tm), t)
+	equals("app-2020-03-04T05.log", formatTimed("app-{2006-01-02}T{15}.log", tm), t)
+	equals("app.log", formatTimed("app.log", tm), t)
+	equals("app-{2006.log", formatTimed("app-{2006.log", tm), t)
+}
+
+func TestTimedFilename(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestTimedFilename", t)
+	defer os.RemoveAll(dir)
+
+	dated := func() string {
+		return filepath.Join(dir, "foo-"+fakeTime().UTC().Format("2006-01-02")+".log")
+	}
+
+	var events []Event
+	l := &Logger{
+		Filename:      filepath.Join(dir, "foo-{2006-01-02}.log"),
+		TimedFilename: true,
+		MaxSize:       10,
+		MaxBackups:    2,
+		ManualCleanup: true,
+		OnEvent:       func(e Event) { events = append(events, e) },
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	first := dated()
+	existsWithContent(first, b, t)
+
+	// when the date changes, the Logger switches to a new file and leaves the
+	// old one as it is.
+	newFakeTime()
+	b2 := []byte("foooooo!")
+	_, err = l.Write(b2)
+	isNil(err, t)
+	second := dated()
+	existsWithContent(first, b, t)
+	existsWithContent(second, b2, t)
+	equals(1, len(events), t)
+	equals(EventRotate, events[0].Type, t)
+	equals(first, events[0].Filename, t)
+
+	// a file that gets too big is rotated as usual.
+	_, err = l.Write(b2)
+	isNil(err, t)
+	secondBackup := backupName(second, false)
+	existsWithContent(secondBackup, b2, t)
+	existsWithContent(second, b2, t)
+
+	newFakeTime()
+	_, err = l.Write(b)
+	isNil(err, t)
+	existsWithContent(dated(), b, t)
+
+	// the old dated file and the backup count as backups, the oldest of
+	// which goes.
+	files, err := l.oldLogFiles()
+	isNil(err, t)
+	equals(3, len(files), t)
+	isNil(l.CleanNow(), t)
+	notExist(first, t)
+	exists(second, t)
+	exists(secondBackup, t)
+	fileCount(dir, 3, t)
+}
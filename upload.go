@@ -0,0 +1,342 @@
+package lumberjack
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// uploadQueueSize is how many finished backups can be waiting to be
+	// uploaded before more are dropped.
+	uploadQueueSize = 100
+
+	defaultUploadAttempts   = 3
+	defaultUploadRetryDelay = time.Second
+)
+
+// Uploader uploads backups somewhere safe, such as an object store, for a
+// Logger with its Uploader field set.
+type Uploader interface {
+	// Upload uploads the file at localPath, naming it objectName.  The file
+	// must not be modified.  It should return promptly once ctx is done.
+	Upload(ctx context.Context, localPath, objectName string) error
+}
+
+// errUploadQueueFull is reported when a backup can't be uploaded because too
+// many others are still waiting.
+var errUploadQueueFull = errors.New("upload queue is full")
+
+// queueUpload records that the log file has been rotated to name, so that it
+// is uploaded once the mill has finished with it.
+func (l *Logger) queueUpload(name string) {
+	if l.Uploader == nil || l.UploadStateFile != "" {
+		return
+	}
+	l.uploadMu.Lock()
+	l.toUpload = append(l.toUpload, name)
+	l.uploadMu.Unlock()
+}
+
+// startUploads hands the backups that are ready to be uploaded to the upload
+// goroutine.  It is called by the mill once it's done compressing, and does
+// nothing if uploads only happen every UploadInterval.  With ManualCleanup,
+// it does the uploads itself instead, ignoring UploadInterval.
+func (l *Logger) startUploads() {
+	if l.Uploader == nil {
+		return
+	}
+	if l.ManualCleanup {
+		for _, name := range l.uploadsReady() {
+			l.uploadOne(name)
+		}
+		return
+	}
+	l.startUpload.Do(func() {
+		l.uploadCh = make(chan string, uploadQueueSize)
+		l.uploadStop = make(chan struct{})
+		l.uploadDone = make(chan struct{})
+		go l.uploadRun(l.uploadCh, l.uploadStop, l.uploadDone)
+	})
+	if l.UploadInterval <= 0 {
+		l.sendUploads(l.uploadCh)
+	}
+}
+
+// stopUploads stops the upload goroutine, if it is running, cancelling any
+// upload that is under way.  Backups that were waiting to be uploaded are
+// kept for when the Logger is used again, or, with an UploadStateFile, for
+// the next time the program runs.  It must be called with millMu held, so
+// that the mill can't start the goroutine again meanwhile.
+func (l *Logger) stopUploads() {
+	l.uploadMu.Lock()
+	if l.uploadCancel != nil {
+		l.uploadCancel()
+	}
+	l.uploadMu.Unlock()
+
+	if l.uploadStop != nil {
+		close(l.uploadStop)
+		<-l.uploadDone
+		l.uploadCh, l.uploadStop, l.uploadDone = nil, nil, nil
+		l.startUpload = sync.Once{}
+	}
+
+	l.uploadMu.Lock()
+	l.uploadCtx, l.uploadCancel = nil, nil
+	l.uploadMu.Unlock()
+}
+
+// uploadContext returns the context to upload with, which is cancelled when
+// the Logger is closed.
+func (l *Logger) uploadContext() context.Context {
+	l.uploadMu.Lock()
+	defer l.uploadMu.Unlock()
+	if l.uploadCtx == nil {
+		l.uploadCtx, l.uploadCancel = context.WithCancel(context.Background())
+	}
+	return l.uploadCtx
+}
+
+// sendUploads queues the backups that are ready to be uploaded on ch.
+func (l *Logger) sendUploads(ch chan string) {
+	for _, name := range l.uploadsReady() {
+		select {
+		case ch <- name:
+		default:
+			l.uploadMu.Lock()
+			delete(l.uploading, filepath.Base(name))
+			l.uploadMu.Unlock()
+			l.emit(Event{Type: EventUpload, Filename: name, Err: errUploadQueueFull})
+		}
+	}
+}
+
+// uploadsReady returns the backups that should be uploaded now, by the names
+// they have after compression.  Without an UploadStateFile, these are the
+// backups rotated since the last call.  With one, they are all the finished
+// backups that it doesn't list, and that aren't already being uploaded.
+func (l *Logger) uploadsReady() []string {
+	l.uploadMu.Lock()
+	defer l.uploadMu.Unlock()
+
+	if l.UploadStateFile == "" {
+		var names []string
+		for _, name := range l.toUpload {
+			if cname := compressedVersion(name); cname != "" {
+				name = cname
+			} else if _, err := os.Stat(name); err != nil {
+				// removed already, nothing to upload.
+				continue
+			}
+			names = append(names, name)
+		}
+		l.toUpload = nil
+		return names
+	}
+
+	if err := l.loadUploaded(); err != nil {
+		l.emit(Event{Type: EventUpload, Filename: l.UploadStateFile, Err: failed(ErrUploadFailed, err)})
+		return nil
+	}
+	files, err := l.oldLogFiles()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	present := map[string]bool{}
+	// oldest first, so they're uploaded in the order they were written.
+	for i := len(files) - 1; i >= 0; i-- {
+		f := files[i].Name()
+		present[f] = true
+		if !isCompressed(f) && l.compresses(files[i].Size()) {
+			// not finished yet.
+			continue
+		}
+		if l.uploaded[f] || l.uploading[f] {
+			continue
+		}
+		l.uploading[f] = true
+		names = append(names, filepath.Join(l.dir(), f))
+	}
+
+	// forget about backups that are gone, so the state file doesn't grow
+	// forever.
+	stale := false
+	for f := range l.uploaded {
+		if !present[f] {
+			delete(l.uploaded, f)
+			stale = true
+		}
+	}
+	if stale {
+		if err := l.saveUploaded(); err != nil {
+			l.emit(Event{Type: EventUpload, Filename: l.UploadStateFile, Err: failed(ErrUploadFailed, err)})
+		}
+	}
+	return names
+}
+
+// loadUploaded reads the names of the backups that have been uploaded from
+// UploadStateFile, if that hasn't been done yet.  It must be called with
+// uploadMu held.
+func (l *Logger) loadUploaded() error {
+	if l.uploaded != nil {
+		return nil
+	}
+	uploaded := map[string]bool{}
+	f, err := os.Open(l.UploadStateFile)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("can't open upload state file: %w", err)
+	}
+	if err == nil {
+		defer f.Close()
+		s := bufio.NewScanner(f)
+		for s.Scan() {
+			if name := strings.TrimSpace(s.Text()); name != "" {
+				uploaded[name] = true
+			}
+		}
+		if err := s.Err(); err != nil {
+			return fmt.Errorf("can't read upload state file: %w", err)
+		}
+	}
+	l.uploaded = uploaded
+	l.uploading = map[string]bool{}
+	return nil
+}
+
+// saveUploaded rewrites UploadStateFile from scratch.  It must be called with
+// uploadMu held.
+func (l *Logger) saveUploaded() error {
+	var b strings.Builder
+	for name := range l.uploaded {
+		b.WriteString(name + "\n")
+	}
+	return writeFileAtomic(l.UploadStateFile, []byte(b.String()), 0600)
+}
+
+// markUploaded records in UploadStateFile that the named backup has been
+// uploaded.
+func (l *Logger) markUploaded(name string) error {
+	if l.UploadStateFile == "" {
+		return nil
+	}
+	base := filepath.Base(name)
+	l.uploadMu.Lock()
+	defer l.uploadMu.Unlock()
+	delete(l.uploading, base)
+	l.uploaded[base] = true
+	f, err := os.OpenFile(l.UploadStateFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("can't open upload state file: %w", err)
+	}
+	_, err = f.WriteString(base + "\n")
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	return err
+}
+
+// uploadRun runs in a goroutine to upload the finished backups sent on ch,
+// and to look for backups to upload every UploadInterval if that is set,
+// until stop is closed.  It closes done when it returns.
+func (l *Logger) uploadRun(ch chan string, stop, done chan struct{}) {
+	defer close(done)
+	var tick <-chan time.Time
+	if l.UploadInterval > 0 {
+		t := time.NewTicker(l.UploadInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-stop:
+			l.unqueueUploads(ch)
+			return
+		default:
+		}
+		select {
+		case <-stop:
+			l.unqueueUploads(ch)
+			return
+		case name := <-ch:
+			l.uploadOne(name)
+		case <-tick:
+			l.sendUploads(ch)
+		}
+	}
+}
+
+// unqueueUploads takes the backups still waiting on ch back, so that they
+// are tried again later.
+func (l *Logger) unqueueUploads(ch chan string) {
+	l.uploadMu.Lock()
+	defer l.uploadMu.Unlock()
+	for {
+		select {
+		case name := <-ch:
+			if l.UploadStateFile != "" {
+				delete(l.uploading, filepath.Base(name))
+			} else {
+				l.toUpload = append(l.toUpload, name)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// uploadOne uploads one backup, and deals with the result.
+func (l *Logger) uploadOne(name string) {
+	err := failed(ErrUploadFailed, l.upload(name))
+	if err == nil {
+		err = l.markUploaded(name)
+	} else if l.UploadStateFile != "" {
+		// let a later pass try again.
+		l.uploadMu.Lock()
+		delete(l.uploading, filepath.Base(name))
+		l.uploadMu.Unlock()
+	}
+	l.emit(Event{Type: EventUpload, Filename: name, Err: err})
+	if err == nil && l.RemoveAfterUpload {
+		if err := removeFile(name); err != nil {
+			l.emit(Event{Type: EventRemove, Filename: name, Err: failed(ErrRemoveFailed, err)})
+		}
+	}
+}
+
+// upload uploads the named file, retrying with backoff if it fails.
+func (l *Logger) upload(name string) error {
+	attempts := l.UploadAttempts
+	if attempts <= 0 {
+		attempts = defaultUploadAttempts
+	}
+	delay := l.UploadRetryDelay
+	if delay <= 0 {
+		delay = defaultUploadRetryDelay
+	}
+	ctx := l.uploadContext()
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+		err = l.Uploader.Upload(ctx, name, filepath.Base(name))
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,260 @@
+package lumberjack
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeUploader records the uploads asked of it, failing the first fail of
+// them.
+type fakeUploader struct {
+	mu       sync.Mutex
+	fail     int
+	attempts int
+	uploaded []string
+}
+
+func (f *fakeUploader) Upload(_ context.Context, localPath, objectName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.fail > 0 {
+		f.fail--
+		return errors.New("upload failed")
+	}
+	if _, err := os.Stat(localPath); err != nil {
+		return err
+	}
+	f.uploaded = append(f.uploaded, objectName)
+	return nil
+}
+
+func TestUploadCompressed(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadCompressed", t)
+	defer os.RemoveAll(dir)
+
+	up := &fakeUploader{fail: 1}
+	l := &Logger{
+		Filename:         logFile(dir),
+		Compress:         true,
+		Uploader:         up,
+		UploadRetryDelay: time.Millisecond,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(300 * time.Millisecond)
+
+	up.mu.Lock()
+	defer up.mu.Unlock()
+	equals(2, up.attempts, t)
+	equals([]string{"foobar-" + fakeTime().UTC().Format(backupTimeFormat) + ".log" + compressSuffix}, up.uploaded, t)
+	// without RemoveAfterUpload the backup is kept.
+	exists(backupFile(dir)+compressSuffix, t)
+}
+
+func TestUploadGiveUp(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadGiveUp", t)
+	defer os.RemoveAll(dir)
+
+	up := &fakeUploader{fail: 10}
+	var mu sync.Mutex
+	var events []Event
+	l := &Logger{
+		Filename:          logFile(dir),
+		Uploader:          up,
+		UploadAttempts:    2,
+		UploadRetryDelay:  time.Millisecond,
+		RemoveAfterUpload: true,
+		OnEvent: func(e Event) {
+			if e.Type != EventUpload {
+				return
+			}
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		},
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(300 * time.Millisecond)
+
+	up.mu.Lock()
+	equals(2, up.attempts, t)
+	up.mu.Unlock()
+	exists(backupFile(dir), t)
+	// failed uploads are reported by events, not as cleanup errors.
+	isNil(l.LastError(), t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals(1, len(events), t)
+	notNil(events[0].Err, t)
+}
+
+func TestUploadStateFile(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadStateFile", t)
+	defer os.RemoveAll(dir)
+
+	// two backups from before a restart, one of them already uploaded.
+	newFakeTime()
+	shipped := filepath.Base(backupFile(dir))
+	isNil(ioutil.WriteFile(backupFile(dir), []byte("one"), 0644), t)
+	newFakeTime()
+	unshipped := filepath.Base(backupFile(dir))
+	isNil(ioutil.WriteFile(backupFile(dir), []byte("two"), 0644), t)
+
+	state := filepath.Join(dir, "shipped")
+	isNil(ioutil.WriteFile(state, []byte(shipped+"\nfoobar-gone.log\n"), 0644), t)
+
+	up := &fakeUploader{}
+	l := &Logger{
+		Filename:        logFile(dir),
+		Uploader:        up,
+		UploadStateFile: state,
+	}
+	defer l.Close()
+
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	rotated := filepath.Base(backupFile(dir))
+
+	<-time.After(300 * time.Millisecond)
+
+	up.mu.Lock()
+	equals([]string{unshipped, rotated}, up.uploaded, t)
+	up.mu.Unlock()
+
+	b, err := ioutil.ReadFile(state)
+	isNil(err, t)
+	lines := strings.Fields(string(b))
+	sort.Strings(lines)
+	equals([]string{shipped, unshipped, rotated}, lines, t)
+
+	// another pass only uploads the new backup.
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-time.After(300 * time.Millisecond)
+	up.mu.Lock()
+	equals([]string{unshipped, rotated, filepath.Base(backupFile(dir))}, up.uploaded, t)
+	up.mu.Unlock()
+}
+
+func TestUploadStopsOnClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadStopsOnClose", t)
+	defer os.RemoveAll(dir)
+
+	before := runtime.NumGoroutine()
+	up := &fakeUploader{}
+	for i := 0; i < 5; i++ {
+		l := &Logger{
+			Filename: logFile(dir),
+			Uploader: up,
+		}
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		isNil(l.Close(), t)
+	}
+	<-time.After(50 * time.Millisecond)
+	after := runtime.NumGoroutine()
+	assert(after <= before, t, "%d goroutines before, %d after", before, after)
+}
+
+// hungUploader blocks in Upload until its context is done, reporting that
+// it has started on started.
+type hungUploader struct {
+	started chan struct{}
+}
+
+func (h *hungUploader) Upload(ctx context.Context, _, _ string) error {
+	close(h.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestUploadCancelledByClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestUploadCancelledByClose", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var errs []error
+	up := &hungUploader{started: make(chan struct{})}
+	l := &Logger{
+		Filename:       logFile(dir),
+		Uploader:       up,
+		UploadAttempts: 1,
+		OnEvent: func(e Event) {
+			if e.Type == EventUpload {
+				mu.Lock()
+				errs = append(errs, e.Err)
+				mu.Unlock()
+			}
+		},
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	<-up.started
+
+	closed := make(chan error)
+	go func() { closed <- l.Close() }()
+	select {
+	case err := <-closed:
+		isNil(err, t)
+	case <-time.After(time.Second):
+		t.Fatal("Close didn't cancel the upload")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	equals(1, len(errs), t)
+	assert(errors.Is(errs[0], context.Canceled), t, "expected the upload to be cancelled, got %v", errs[0])
+}
+
+func TestSCPArgs(t *testing.T) {
+	s := &SCPUploader{
+		Host:         "backup@logs",
+		Port:         2222,
+		Dir:          "/srv/logs",
+		IdentityFile: "/etc/key",
+		Args:         []string{"-o", "StrictHostKeyChecking=yes"},
+	}
+	equals([]string{
+		"-q", "-B", "-s", "-P", "2222", "-i", "/etc/key", "-o", "StrictHostKeyChecking=yes",
+		"/var/log/foo.log.gz", "backup@logs:/srv/logs/foo.log.gz",
+	}, s.args("/var/log/foo.log.gz", "foo.log.gz"), t)
+
+	// with SFTP, a remote path with spaces or shell metacharacters is passed
+	// as it is, with nothing to quote.
+	s = &SCPUploader{Host: "logs", Dir: "/srv/app logs"}
+	equals([]string{
+		"-q", "-B", "-s", "/var/log/foo.log.gz", "logs:/srv/app logs/foo;$(x).log.gz",
+	}, s.args("/var/log/foo.log.gz", "foo;$(x).log.gz"), t)
+}
@@ -0,0 +1,163 @@
+package lumberjack
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DiskUsage is a breakdown of the disk space taken up by a Logger's files,
+// as returned by Usage.  Its String method formats it as a table for
+// admin endpoints and the like, and it can be encoded as JSON.
+type DiskUsage struct {
+	// LogFile is the size in bytes of the log file.
+	LogFile int64 `json:"log_file"`
+
+	// Backups describes the backups, newest first.
+	Backups []BackupUsage `json:"backups"`
+
+	// RawSize is the total size in bytes of the log data in the backups,
+	// before any compression, and DiskSize is the total size of the backup
+	// files.
+	RawSize  int64 `json:"raw_size"`
+	DiskSize int64 `json:"disk_size"`
+}
+
+// BackupUsage describes the disk space taken up by one backup.
+type BackupUsage struct {
+	// Name is the backup's file name, relative to the log file's directory.
+	Name string `json:"name"`
+
+	// RotatedAt is the rotation time encoded in the backup's name, and Age
+	// is how long ago that was.  Age is left out of JSON, where RotatedAt
+	// says the same.
+	RotatedAt time.Time     `json:"rotated_at"`
+	Age       time.Duration `json:"-"`
+
+	// RawSize is the size in bytes of the log data, before any compression.
+	RawSize int64 `json:"raw_size"`
+
+	// CompressedSize is the size of the backup file in bytes if it is
+	// compressed, and zero otherwise.
+	CompressedSize int64 `json:"compressed_size,omitempty"`
+}
+
+// Usage returns a breakdown of the disk space taken up by the log file and
+// each of its backups.  Compressed backups are read through to find the size
+// of the log data in them, which takes a while when there are many large
+// ones; with WriteIndex set, the sizes are taken from the index instead
+// wherever it is up to date.
+func (l *Logger) Usage() (DiskUsage, error) {
+	l.mu.Lock()
+	name := l.filename()
+	files, err := l.oldLogFiles()
+	l.mu.Unlock()
+	if err != nil {
+		return DiskUsage{}, err
+	}
+
+	var u DiskUsage
+	if info, err := l.stat(name); err == nil {
+		u.LogFile = info.Size()
+	}
+	known := map[string]BackupRecord{}
+	if l.WriteIndex {
+		if idx, err := ReadIndex(name + indexSuffix); err == nil {
+			for _, r := range idx.Backups {
+				known[r.Name] = r
+			}
+		}
+	}
+	now := currentTime()
+	u.Backups = []BackupUsage{}
+	for _, f := range files {
+		b := BackupUsage{
+			Name:      f.Name(),
+			RotatedAt: f.timestamp,
+			Age:       now.Sub(f.timestamp),
+			RawSize:   f.Size(),
+		}
+		if c := codecFor(f.Name()); c != nil {
+			b.CompressedSize = f.Size()
+			if r, ok := known[f.Name()]; ok && r.CompressedSize == f.Size() {
+				b.RawSize = r.Size
+			} else if b.RawSize, err = rawSize(filepath.Join(filepath.Dir(name), f.Name()), c); err != nil {
+				if os.IsNotExist(err) {
+					// removed by cleanup since it was listed.
+					continue
+				}
+				return DiskUsage{}, err
+			}
+		}
+		u.Backups = append(u.Backups, b)
+		u.RawSize += b.RawSize
+		u.DiskSize += f.Size()
+	}
+	return u, nil
+}
+
+// rawSize returns the size of the data in the backup name, compressed with
+// c.
+func rawSize(name string, c *codec) (int64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r, err := c.decompress(f)
+	if err != nil {
+		return 0, fmt.Errorf("can't read compressed backup %s: %w", name, err)
+	}
+	defer r.Close()
+	n, err := io.Copy(ioutil.Discard, r)
+	if err != nil {
+		return 0, fmt.Errorf("can't read compressed backup %s: %w", name, err)
+	}
+	return n, nil
+}
+
+// CompressionRatio returns the ratio of the backups' size on disk to the size
+// of the log data in them, or zero if there is no data.
+func (u DiskUsage) CompressionRatio() float64 {
+	if u.RawSize == 0 {
+		return 0
+	}
+	return float64(u.DiskSize) / float64(u.RawSize)
+}
+
+// String formats u as a table of the backups followed by the totals.
+func (u DiskUsage) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tAGE\tSIZE\tCOMPRESSED")
+	for _, bu := range u.Backups {
+		compressed := "-"
+		if bu.CompressedSize != 0 {
+			compressed = formatBytes(bu.CompressedSize)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", bu.Name, bu.Age.Round(time.Second), formatBytes(bu.RawSize), compressed)
+	}
+	w.Flush()
+	fmt.Fprintf(&b, "%d backups, %s of logs in %s on disk (ratio %.2f), log file %s\n",
+		len(u.Backups), formatBytes(u.RawSize), formatBytes(u.DiskSize), u.CompressionRatio(), formatBytes(u.LogFile))
+	return b.String()
+}
+
+// formatBytes formats n bytes with a binary unit, as in "1.5 MiB".
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	f := float64(n)
+	unit := -1
+	for f >= 1024 && unit < 5 {
+		f /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %ciB", f, "KMGTPE"[unit])
+}
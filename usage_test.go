@@ -0,0 +1,71 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsage(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestUsage", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{Filename: logFile(dir)}
+	defer l.Close()
+
+	u, err := l.Usage()
+	isNil(err, t)
+	equals(0, len(u.Backups), t)
+	equals(float64(0), u.CompressionRatio(), t)
+
+	old := backupFile(dir)
+	data := bytes.Repeat([]byte("boo!"), 100)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(data)
+	isNil(err, t)
+	isNil(gz.Close(), t)
+	isNil(ioutil.WriteFile(old+compressSuffix, buf.Bytes(), 0644), t)
+
+	newFakeTime()
+	isNil(ioutil.WriteFile(backupFile(dir), []byte("foo!"), 0644), t)
+	newFakeTime()
+	_, err = l.Write([]byte("boo!boo!"))
+	isNil(err, t)
+
+	u, err = l.Usage()
+	isNil(err, t)
+	equals(int64(8), u.LogFile, t)
+	equals(2, len(u.Backups), t)
+	equals("foobar-"+fakeTime().Add(-48*time.Hour).UTC().Format(backupTimeFormat)+".log", u.Backups[0].Name, t)
+	equals(int64(4), u.Backups[0].RawSize, t)
+	equals(int64(0), u.Backups[0].CompressedSize, t)
+	equals(48*time.Hour, u.Backups[0].Age.Round(time.Second), t)
+	equals(int64(len(data)), u.Backups[1].RawSize, t)
+	equals(int64(buf.Len()), u.Backups[1].CompressedSize, t)
+	equals(int64(len(data)+4), u.RawSize, t)
+	equals(int64(buf.Len()+4), u.DiskSize, t)
+	equals(float64(buf.Len()+4)/float64(len(data)+4), u.CompressionRatio(), t)
+
+	s := u.String()
+	assert(strings.Contains(s, u.Backups[1].Name), t, "expected the backup in %q", s)
+	assert(strings.Contains(s, "2 backups, 404 B of logs"), t, "expected the totals in %q", s)
+
+	b, err := json.Marshal(u)
+	isNil(err, t)
+	assert(strings.Contains(string(b), `"raw_size":404`), t, "expected the raw size in %s", b)
+}
+
+func TestFormatBytes(t *testing.T) {
+	equals("0 B", formatBytes(0), t)
+	equals("1023 B", formatBytes(1023), t)
+	equals("1.0 KiB", formatBytes(1024), t)
+	equals("1.5 MiB", formatBytes(3<<19), t)
+}
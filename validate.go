@@ -0,0 +1,96 @@
+package lumberjack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits on file names and paths.  Windows' limit on paths doesn't apply to
+// ones that start with `\\?\`.
+const (
+	maxNameLen        = 255
+	maxPathLenUnix    = 4096
+	maxPathLenWindows = 260
+)
+
+// windowsReserved are the device names that Windows won't use as file names,
+// even with an extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// validateName checks that the log file name, and the names of backups made
+// from it, which are extra bytes longer, are allowed on the operating system
+// goos, returning an error wrapping ErrInvalidName if not.
+func validateName(name, goos string, extra int) error {
+	if strings.IndexByte(name, 0) >= 0 {
+		return fmt.Errorf("%w: %q contains a NUL byte", ErrInvalidName, name)
+	}
+	if goos != "windows" {
+		if len(name)+extra > maxPathLenUnix {
+			return fmt.Errorf("%w: backup paths would be %d bytes long, over the limit of %d", ErrInvalidName, len(name)+extra, maxPathLenUnix)
+		}
+		elems := strings.Split(name, "/")
+		if base := elems[len(elems)-1]; len(base)+extra > maxNameLen {
+			return fmt.Errorf("%w: backup names would be %d bytes long, over the limit of %d", ErrInvalidName, len(base)+extra, maxNameLen)
+		}
+		return nil
+	}
+
+	path := name
+	long := strings.HasPrefix(path, `\\?\`)
+	if long {
+		path = path[len(`\\?\`):]
+	}
+	if len(path) >= 2 && path[1] == ':' {
+		path = path[2:]
+	}
+	if !long && len(name)+extra >= maxPathLenWindows {
+		return fmt.Errorf("%w: backup paths would be %d characters long, over the limit of %d", ErrInvalidName, len(name)+extra, maxPathLenWindows-1)
+	}
+	elems := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' })
+	for i, elem := range elems {
+		if elem == "." || elem == ".." {
+			continue
+		}
+		for _, r := range elem {
+			if r < 32 || strings.ContainsRune(`<>:"|?*`, r) {
+				return fmt.Errorf("%w: %q contains %q, which Windows doesn't allow", ErrInvalidName, elem, r)
+			}
+		}
+		if strings.HasSuffix(elem, ".") || strings.HasSuffix(elem, " ") {
+			return fmt.Errorf("%w: %q ends with a dot or space, which Windows drops", ErrInvalidName, elem)
+		}
+		stem := elem
+		if j := strings.IndexByte(stem, '.'); j >= 0 {
+			stem = stem[:j]
+		}
+		if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+			return fmt.Errorf("%w: %q is a device name on Windows", ErrInvalidName, elem)
+		}
+		if i == len(elems)-1 && len(elem)+extra > maxNameLen {
+			return fmt.Errorf("%w: backup names would be %d characters long, over the limit of %d", ErrInvalidName, len(elem)+extra, maxNameLen)
+		}
+	}
+	return nil
+}
+
+// backupNameExtra returns how much longer the names of backups are than the
+// log file's name.
+func (l *Logger) backupNameExtra() int {
+	extra := len("-") + len(backupTimeFormat)
+	if l.NumericBackups {
+		// allowing for backup numbers of up to four digits.
+		extra = len(".1000")
+	}
+	if l.Compress && !l.CompressActive {
+		if c, err := l.codec(); err == nil {
+			extra += len(c.ext)
+		}
+	}
+	return extra
+}
@@ -0,0 +1,112 @@
+package lumberjack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// webhookQueueSize is how many events can be waiting to be POSTed to the
+// WebhookURL before more are dropped.
+const webhookQueueSize = 100
+
+// webhookClient is the client used to POST events.
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// defaultWebhookDrainTimeout is how long Close waits for the events that are
+// queued to be sent, if WebhookDrainTimeout isn't set.
+const defaultWebhookDrainTimeout = 10 * time.Second
+
+// sendWebhook queues e to be POSTed to the WebhookURL, starting the goroutine
+// that does that if necessary.  Events are dropped once the Logger has been
+// closed, until it is reopened.
+func (l *Logger) sendWebhook(e Event) {
+	l.webhookMu.Lock()
+	defer l.webhookMu.Unlock()
+	if l.webhookStopped {
+		return
+	}
+	if l.webhookCh == nil {
+		var ctx context.Context
+		ctx, l.webhookCancel = context.WithCancel(context.Background())
+		l.webhookCh = make(chan Event, webhookQueueSize)
+		l.webhookDone = make(chan struct{})
+		go l.webhookRun(ctx, l.webhookCh, l.webhookDone)
+	}
+	select {
+	case l.webhookCh <- e:
+	default:
+	}
+}
+
+// stopWebhook stops the goroutine that POSTs events to the WebhookURL, if it
+// is running, so that no more events are sent until restartWebhook is called.
+// It returns a function that waits until the events that are waiting have
+// been sent or WebhookDrainTimeout has passed, whichever is first, to be
+// called without l.mu held, so that a slow endpoint doesn't hold up the
+// Logger.
+func (l *Logger) stopWebhook() (wait func()) {
+	l.webhookMu.Lock()
+	ch, done, cancel := l.webhookCh, l.webhookDone, l.webhookCancel
+	l.webhookCh, l.webhookDone, l.webhookCancel = nil, nil, nil
+	l.webhookStopped = true
+	l.webhookMu.Unlock()
+	if ch == nil {
+		return func() {}
+	}
+	close(ch)
+	timeout := l.WebhookDrainTimeout
+	if timeout <= 0 {
+		timeout = defaultWebhookDrainTimeout
+	}
+	return func() {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		select {
+		case <-done:
+		case <-t.C:
+		}
+		cancel()
+		<-done
+	}
+}
+
+// restartWebhook lets events be sent to the WebhookURL again after
+// stopWebhook, when a closed Logger is reopened.
+func (l *Logger) restartWebhook() {
+	l.webhookMu.Lock()
+	l.webhookStopped = false
+	l.webhookMu.Unlock()
+}
+
+// webhookRun runs in a goroutine to POST the events sent on ch to the
+// WebhookURL, until ch is closed or ctx is cancelled.  It closes done when it
+// returns.
+func (l *Logger) webhookRun(ctx context.Context, ch chan Event, done chan struct{}) {
+	defer close(done)
+	for e := range ch {
+		if ctx.Err() != nil {
+			return
+		}
+		b, err := json.Marshal(e)
+		if err != nil {
+			continue
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.WebhookURL, bytes.NewReader(b))
+		if err != nil {
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			// what am I going to do, log this?
+			continue
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
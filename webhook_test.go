@@ -0,0 +1,184 @@
+package lumberjack
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWebhook", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var got []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		equals("application/json", r.Header.Get("Content-Type"), t)
+		b, err := ioutil.ReadAll(r.Body)
+		isNil(err, t)
+		var v map[string]interface{}
+		isNil(json.Unmarshal(b, &v), t)
+		mu.Lock()
+		got = append(got, v)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	l := &Logger{
+		Filename:   logFile(dir),
+		Compress:   true,
+		WebhookURL: srv.URL,
+	}
+	defer l.Close()
+
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	<-time.After(300 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	equals(2, len(got), t)
+	equals("rotate", got[0]["type"], t)
+	equals(backupFile(dir), got[0]["filename"], t)
+	equals(logFile(dir), got[0]["source"], t)
+	equals(float64(len(b)), got[0]["size"], t)
+	equals("compress", got[1]["type"], t)
+	equals(backupFile(dir)+compressSuffix, got[1]["filename"], t)
+	equals(backupFile(dir), got[1]["source"], t)
+}
+
+func TestEventJSON(t *testing.T) {
+	e := Event{
+		Type:     EventRemove,
+		Time:     time.Date(2016, 11, 4, 18, 30, 0, 0, time.UTC),
+		Filename: "/var/log/foo/server-2016-11-04T18-30-00.000.log",
+		Err:      errors.New("boom"),
+	}
+	b, err := json.Marshal(e)
+	isNil(err, t)
+	equals(`{"type":"remove","time":"2016-11-04T18:30:00Z",`+
+		`"filename":"/var/log/foo/server-2016-11-04T18-30-00.000.log","error":"boom"}`, string(b), t)
+}
+
+func TestWebhookClose(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWebhookClose", t)
+	defer os.RemoveAll(dir)
+
+	var mu sync.Mutex
+	var got int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 5; i++ {
+		l := &Logger{
+			Filename:   logFile(dir),
+			WebhookURL: srv.URL,
+		}
+		_, err := l.Write([]byte("boo!"))
+		isNil(err, t)
+		newFakeTime()
+		isNil(l.Rotate(), t)
+		// Close sends the rotation event before returning.
+		isNil(l.Close(), t)
+		mu.Lock()
+		equals(i+1, got, t)
+		mu.Unlock()
+	}
+	srv.CloseClientConnections()
+	<-time.After(50 * time.Millisecond)
+	after := runtime.NumGoroutine()
+	assert(after <= before, t, "%d goroutines before, %d after", before, after)
+}
+
+func TestWebhookCloseDeadline(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWebhookCloseDeadline", t)
+	defer os.RemoveAll(dir)
+
+	// an endpoint that never answers.
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	l := &Logger{
+		Filename:            logFile(dir),
+		WebhookURL:          srv.URL,
+		WebhookDrainTimeout: 50 * time.Millisecond,
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	for i := 0; i < 10; i++ {
+		newFakeTime()
+		isNil(l.Rotate(), t)
+	}
+
+	// Close gives up on the queued events once the deadline has passed.
+	start := time.Now()
+	isNil(l.Close(), t)
+	took := time.Since(start)
+	assert(took < time.Second, t, "Close took %v", took)
+
+	// and events after Close don't start sending again.
+	l.emit(Event{Type: EventRotate})
+	l.webhookMu.Lock()
+	running := l.webhookCh != nil
+	l.webhookMu.Unlock()
+	assert(!running, t, "expected no webhook goroutine after Close")
+}
+
+func TestWebhookCloseDoesNotBlock(t *testing.T) {
+	currentTime = fakeTime
+	dir := makeTempDir("TestWebhookCloseDoesNotBlock", t)
+	defer os.RemoveAll(dir)
+
+	// an endpoint that never answers.
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	l := &Logger{
+		Filename:            logFile(dir),
+		WebhookURL:          srv.URL,
+		WebhookDrainTimeout: time.Second,
+	}
+	_, err := l.Write([]byte("boo!"))
+	isNil(err, t)
+	newFakeTime()
+	isNil(l.Rotate(), t)
+
+	// while Close waits for the webhook, the Logger isn't locked.
+	closed := make(chan error)
+	go func() { closed <- l.Close() }()
+	<-time.After(50 * time.Millisecond)
+	start := time.Now()
+	err = l.Health()
+	assert(errors.Is(err, ErrClosed), t, "expected ErrClosed, got %v", err)
+	took := time.Since(start)
+	assert(took < 500*time.Millisecond, t, "Health took %v", took)
+	isNil(<-closed, t)
+}
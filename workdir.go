@@ -0,0 +1,23 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// workName returns the name to write the compressed backup dst under until
+// it is complete: its name in WorkDir, which is created if need be, or dst
+// itself if WorkDir isn't set.
+func (l *Logger) workName(dst string) (string, error) {
+	if l.WorkDir == "" {
+		return dst, nil
+	}
+	dir := l.WorkDir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(dst), dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(dst)), nil
+}
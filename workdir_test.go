@@ -0,0 +1,42 @@
+package lumberjack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorkDir(t *testing.T) {
+	currentTime = fakeTime
+	megabyte = 1
+	dir := makeTempDir("TestWorkDir", t)
+	defer os.RemoveAll(dir)
+
+	l := &Logger{
+		Filename:      logFile(dir),
+		Compress:      true,
+		ManualCleanup: true,
+		WorkDir:       ".work",
+	}
+	defer l.Close()
+	b := []byte("boo!")
+	_, err := l.Write(b)
+	isNil(err, t)
+
+	newFakeTime()
+	isNil(l.Rotate(), t)
+	isNil(l.CleanNow(), t)
+
+	backup := backupFile(dir)
+	notExist(backup, t)
+	exists(backup+compressSuffix, t)
+	work := filepath.Join(dir, ".work")
+	exists(work, t)
+	fileCount(work, 0, t)
+
+	// the work directory isn't mistaken for a backup
+	l.MaxBackups = 1
+	isNil(l.CleanNow(), t)
+	exists(backup+compressSuffix, t)
+	exists(work, t)
+}